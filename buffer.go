@@ -15,6 +15,9 @@ const _size = 1024
 type Buffer struct {
 	jbuff.JBuffer
 	pool Pool
+	lv   Level // level of the record rendered by the logger
+	flag int   // offset of its [L] flag
+	enc  bufferEncoder
 }
 
 func (b *Buffer) Free() { b.pool.put(b) }
@@ -44,6 +47,7 @@ func (p Pool) Get() *Buffer {
 	buf := p.p.Get().(*Buffer)
 	buf.Reset()
 	buf.pool = p
+	buf.enc.redact = nil
 	return buf
 }
 
@@ -0,0 +1,18 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"time"
+)
+
+// Clock tells the logger the time of the records and of the file rotation.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
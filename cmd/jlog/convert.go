@@ -0,0 +1,119 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/jbuff"
+	"github.com/tiger-game/jlog/reader"
+)
+
+// writeJSON writes rec as a JSON line,
+//
+//	{"time":"...","level":"INFO","prefix":"db","msg":"...","caller":"file.go:12"}
+//
+// without the empty prefix and caller.
+func writeJSON(bw *bufio.Writer, rec *reader.Record) {
+	var tmp [64]byte
+	_, _ = bw.WriteString(`{"time":"`)
+	_, _ = bw.Write(rec.Time.AppendFormat(tmp[:0], time.RFC3339Nano))
+	_, _ = bw.WriteString(`","level":"`)
+	_, _ = bw.WriteString(jlog.LevelNames[rec.Level])
+	_ = bw.WriteByte('"')
+	if rec.Prefix != "" {
+		_, _ = bw.WriteString(`,"prefix":`)
+		writeQuoted(bw, rec.Prefix)
+	}
+	_, _ = bw.WriteString(`,"msg":`)
+	writeQuoted(bw, rec.Body)
+	if rec.File != "" {
+		_, _ = bw.WriteString(`,"caller":`)
+		writeQuoted(bw, rec.File+":"+strconv.Itoa(rec.Line))
+	}
+	_, _ = bw.WriteString("}\n")
+}
+
+// writeLogfmt writes rec as a logfmt line,
+//
+//	time=... level=INFO prefix=db msg="..." caller=file.go:12
+//
+// without the empty prefix and caller.
+func writeLogfmt(bw *bufio.Writer, rec *reader.Record) {
+	var tmp [64]byte
+	_, _ = bw.WriteString("time=")
+	_, _ = bw.Write(rec.Time.AppendFormat(tmp[:0], time.RFC3339Nano))
+	_, _ = bw.WriteString(" level=")
+	_, _ = bw.WriteString(jlog.LevelNames[rec.Level])
+	if rec.Prefix != "" {
+		_, _ = bw.WriteString(" prefix=")
+		writeLogfmtValue(bw, rec.Prefix)
+	}
+	_, _ = bw.WriteString(" msg=")
+	writeLogfmtValue(bw, rec.Body)
+	if rec.File != "" {
+		_, _ = bw.WriteString(" caller=")
+		writeLogfmtValue(bw, rec.File+":"+strconv.Itoa(rec.Line))
+	}
+	_ = bw.WriteByte('\n')
+}
+
+func writeLogfmtValue(bw *bufio.Writer, s string) {
+	if s != "" && !strings.ContainsAny(s, " =\"\\") && !hasControl(s) {
+		_, _ = bw.WriteString(s)
+		return
+	}
+	writeQuoted(bw, s)
+}
+
+func hasControl(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// writeQuoted writes s quoted as a JSON string, which logfmt reads too.
+func writeQuoted(bw *bufio.Writer, s string) {
+	var tmp [128]byte
+	_, _ = bw.Write(jbuff.AppendJSONString(tmp[:0], s))
+}
+
+func runConvert(args []string) error {
+	var rf readFlags
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	rf.register(fs)
+	to := fs.String("to", "json", "the output format, json or logfmt")
+	_ = fs.Parse(args)
+
+	var write func(bw *bufio.Writer, rec *reader.Record)
+	switch *to {
+	case "json":
+		write = writeJSON
+	case "logfmt":
+		write = writeLogfmt
+	default:
+		return fmt.Errorf("unknown format %q, want json or logfmt", *to)
+	}
+	opts, err := rf.options()
+	if err != nil {
+		return err
+	}
+	m, err := openPaths(fs.Args(), jlog.DEBUG, opts)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return copyRecords(os.Stdout, m, nil, write)
+}
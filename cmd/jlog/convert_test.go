@@ -0,0 +1,125 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/reader"
+)
+
+var convertCases = []struct {
+	rec    reader.Record
+	json   string
+	logfmt string
+}{
+	{
+		reader.Record{Level: jlog.INFO, Body: "started"},
+		`{"time":"2026-10-18T09:05:07.123456Z","level":"INFO","msg":"started"}`,
+		`time=2026-10-18T09:05:07.123456Z level=INFO msg=started`,
+	},
+	{
+		reader.Record{Level: jlog.ERROR, Prefix: "db", Body: `a "quoted" \ path`, File: "main.go", Line: 12},
+		`{"time":"2026-10-18T09:05:07.123456Z","level":"ERROR","prefix":"db","msg":"a \"quoted\" \\ path","caller":"main.go:12"}`,
+		`time=2026-10-18T09:05:07.123456Z level=ERROR prefix=db msg="a \"quoted\" \\ path" caller=main.go:12`,
+	},
+	{
+		reader.Record{Level: jlog.WARN, Body: "line1\nline2\t\x01\x7f<&>"},
+		`{"time":"2026-10-18T09:05:07.123456Z","level":"WARN","msg":"line1\nline2\t\u0001` + "\x7f" + `<&>"}`,
+		`time=2026-10-18T09:05:07.123456Z level=WARN msg="line1\nline2\t\u0001` + "\x7f" + `<&>"`,
+	},
+	{
+		reader.Record{Level: jlog.DEBUG, Prefix: "my app", Body: "k=v", File: "dir name/a.go", Line: 3},
+		`{"time":"2026-10-18T09:05:07.123456Z","level":"DEBUG","prefix":"my app","msg":"k=v","caller":"dir name/a.go:3"}`,
+		`time=2026-10-18T09:05:07.123456Z level=DEBUG prefix="my app" msg="k=v" caller="dir name/a.go:3"`,
+	},
+	{
+		reader.Record{Level: jlog.INFO, Body: "bad \xff utf-8 é"},
+		`{"time":"2026-10-18T09:05:07.123456Z","level":"INFO","msg":"bad \ufffd utf-8 é"}`,
+		`time=2026-10-18T09:05:07.123456Z level=INFO msg="bad \ufffd utf-8 é"`,
+	},
+	{
+		reader.Record{Level: jlog.INFO, Body: ""},
+		`{"time":"2026-10-18T09:05:07.123456Z","level":"INFO","msg":""}`,
+		`time=2026-10-18T09:05:07.123456Z level=INFO msg=""`,
+	},
+}
+
+// convert returns what write writes of rec, without the line break.
+func convert(write func(bw *bufio.Writer, rec *reader.Record), rec reader.Record) string {
+	var out bytes.Buffer
+	bw := bufio.NewWriter(&out)
+	write(bw, &rec)
+	_ = bw.Flush()
+	return string(bytes.TrimSuffix(out.Bytes(), []byte("\n")))
+}
+
+func TestWriteJSON(t *testing.T) {
+	at := time.Date(2026, 10, 18, 9, 5, 7, 123456000, time.UTC)
+	for i, c := range convertCases {
+		c.rec.Time = at
+		got := convert(writeJSON, c.rec)
+		if got != c.json {
+			t.Errorf("case %d: got  %s\nwant %s", i, got, c.json)
+			continue
+		}
+		var v struct{ Msg string }
+		if err := json.Unmarshal([]byte(got), &v); err != nil {
+			t.Errorf("case %d: invalid JSON %s: %v", i, got, err)
+		}
+	}
+}
+
+func TestWriteLogfmt(t *testing.T) {
+	at := time.Date(2026, 10, 18, 9, 5, 7, 123456000, time.UTC)
+	for i, c := range convertCases {
+		c.rec.Time = at
+		if got := convert(writeLogfmt, c.rec); got != c.logfmt {
+			t.Errorf("case %d: got  %s\nwant %s", i, got, c.logfmt)
+		}
+	}
+}
+
+func TestWriteConcurrent(t *testing.T) {
+	at := time.Date(2026, 10, 18, 9, 5, 7, 123456000, time.UTC)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, c := range convertCases {
+				c.rec.Time = at
+				if got := convert(writeJSON, c.rec); got != c.json {
+					t.Errorf("case %d: got  %s\nwant %s", i, got, c.json)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestWriteTextTimeFormat writes the records back in the -time-format they
+// were read with.
+func TestWriteTextTimeFormat(t *testing.T) {
+	at := time.Date(2026, 10, 18, 9, 5, 7, 123456000, time.UTC)
+	for layout, want := range map[string]string{
+		jlog.TimeFormatGlog:      "20261018 09:05:07.123456 [W]:[db]slow [main.go:12]",
+		jlog.TimeFormatUnixMilli: "1792314307123 [W]:[db]slow [main.go:12]",
+		jlog.TimeFormatUnixNano:  "1792314307123456000 [W]:[db]slow [main.go:12]",
+		time.RFC3339Nano:         "2026-10-18T09:05:07.123456Z [W]:[db]slow [main.go:12]",
+	} {
+		rf := readFlags{timeFormat: layout}
+		rec := reader.Record{Time: at, Level: jlog.WARN, Prefix: "db", Body: "slow", File: "main.go", Line: 12}
+		if got := convert(rf.writeText, rec); got != want {
+			t.Errorf("%q: got %s, want %s", layout, got, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/reader"
+)
+
+// timeLayouts are the layouts of -since and -until.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"20060102 15:04:05",
+}
+
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("bad time " + s + ", want RFC 3339 or 2006-01-02 15:04:05")
+}
+
+type grepFilter struct {
+	re     *regexp.Regexp
+	level  jlog.Level
+	prefix string
+	since  time.Time
+	until  time.Time
+}
+
+func (g *grepFilter) keep(rec *reader.Record) bool {
+	switch {
+	case rec.Level < g.level:
+		return false
+	case g.prefix != "" && rec.Prefix != g.prefix:
+		return false
+	case !g.since.IsZero() && rec.Time.Before(g.since):
+		return false
+	case !g.until.IsZero() && !rec.Time.Before(g.until):
+		return false
+	}
+	return g.re.MatchString(rec.Body)
+}
+
+func runGrep(args []string) error {
+	var (
+		rf                  readFlags
+		level, since, until string
+		g                   grepFilter
+	)
+	fs := flag.NewFlagSet("grep", flag.ExitOnError)
+	rf.register(fs)
+	fs.StringVar(&level, "level", "DEBUG", "the lowest level of the records")
+	fs.StringVar(&g.prefix, "prefix", "", "the prefix of the records")
+	fs.StringVar(&since, "since", "", "the records from this time")
+	fs.StringVar(&until, "until", "", "the records before this time")
+	_ = fs.Parse(args)
+	if fs.NArg() < 2 {
+		return errors.New("want a pattern and a path")
+	}
+
+	opts, err := rf.options()
+	if err != nil {
+		return err
+	}
+	if g.level, err = jlog.ParseLevel(level); err != nil {
+		return err
+	}
+	loc, _ := time.LoadLocation(rf.tz)
+	if since != "" {
+		if g.since, err = parseTime(since, loc); err != nil {
+			return err
+		}
+	}
+	if until != "" {
+		if g.until, err = parseTime(until, loc); err != nil {
+			return err
+		}
+	}
+	if g.re, err = regexp.Compile(fs.Arg(0)); err != nil {
+		return err
+	}
+
+	m, err := openPaths(fs.Args()[1:], g.level, opts)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return copyRecords(os.Stdout, m, g.keep, rf.writeText)
+}
@@ -0,0 +1,254 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command jlog reads the log directories of jlog.
+//
+//	jlog tail [-f] [-n lines] logName.ext
+//	jlog grep [-level L] [-prefix P] [-since T] [-until T] pattern path...
+//	jlog merge path...
+//	jlog convert [-to json|logfmt] path...
+//
+// A path is a log directory, a file or - for the standard input, the
+// rotated sets are merged into a single chronological stream without the
+// duplicated records, the .gz files decompressed on the fly. Of a directory
+// only the set of a level is read, the files of a level holding the records
+// of the levels above.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/reader"
+)
+
+var commands = []struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}{
+	{"tail", "[-f] [-n lines] logName.ext", runTail},
+	{"grep", "[-level L] [-prefix P] [-since T] [-until T] pattern path...", runGrep},
+	{"merge", "path...", runMerge},
+	{"convert", "[-to json|logfmt] path...", runConvert},
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == flag.Arg(0) {
+			if err := c.run(flag.Args()[1:]); err != nil {
+				fmt.Fprintln(os.Stderr, "jlog "+c.name+":", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "jlog: unknown command %q\n", flag.Arg(0))
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "\tjlog %s %s\n", c.name, c.usage)
+	}
+}
+
+// readFlags are the reader.Options of the commands reading records.
+type readFlags struct {
+	tz         string
+	timeFormat string
+	unescape   bool
+	indent     bool
+}
+
+func (f *readFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.tz, "tz", "Local", "time zone of the logs, jlog.LogTimeZone")
+	fs.StringVar(&f.timeFormat, "time-format", jlog.TimeFormatGlog, "time format of the logs, jlog.LogTimeFormat")
+	fs.BoolVar(&f.unescape, "unescape", false, "decode the bodies of jlog.MultilineEscape")
+	fs.BoolVar(&f.indent, "indent", false, "strip the continuation markers of jlog.MultilineIndent")
+}
+
+func (f *readFlags) options() ([]reader.Option, error) {
+	loc, err := time.LoadLocation(f.tz)
+	if err != nil {
+		return nil, err
+	}
+	return []reader.Option{reader.Location(loc), reader.TimeFormat(f.timeFormat), reader.Unescape(f.unescape),
+		reader.Indent(f.indent)}, nil
+}
+
+// openPaths merges the records of the log directories and files of paths,
+// the rotated files read by set, of the directories only the sets holding
+// the records of level lv and above.
+func openPaths(paths []string, lv jlog.Level, opts []reader.Option) (*reader.Merger, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no path")
+	}
+	var (
+		files []reader.File
+		dirs  []reader.File
+		rs    []*reader.Reader
+	)
+	for _, path := range paths {
+		if path == "-" {
+			rs = append(rs, reader.NewReader(os.Stdin, opts...))
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			dir, err := reader.Files(path, opts...)
+			if err != nil {
+				return nil, err
+			}
+			dirs = append(dirs, dir...)
+			continue
+		}
+		if f, ok := reader.ParseFileName(path, opts...); ok {
+			files = append(files, f)
+			continue
+		}
+		rs = append(rs, reader.OpenFiles([]reader.File{{Path: path}}, opts...))
+	}
+	for _, set := range append(reader.Sets(files), levelSets(dirs, lv)...) {
+		rs = append(rs, reader.OpenFiles(set, opts...))
+	}
+	return reader.Merge(rs...), nil
+}
+
+// levelSets returns the sets of files holding the records of level lv and
+// above, one per app. A file of a level holds the records of the levels
+// above too, the set of the highest level up to lv is read, INFO at least,
+// or the lowest one when there is none. The DEBUG set is added for DEBUG,
+// the dev builds may have written it for some hours only.
+func levelSets(files []reader.File, lv jlog.Level) [][]reader.File {
+	want := lv
+	if want < jlog.INFO {
+		want = jlog.INFO
+	}
+	// better reports whether a set of level a is read rather than one of b.
+	better := func(a, b jlog.Level) bool {
+		if (a <= want) != (b <= want) {
+			return a <= want
+		}
+		if a <= want {
+			return a > b
+		}
+		return a < b
+	}
+
+	var (
+		sets [][]reader.File
+		best = map[string]int{}
+	)
+	for _, set := range reader.Sets(files) {
+		f := set[0]
+		if f.Level == jlog.DEBUG {
+			if lv == jlog.DEBUG {
+				sets = append(sets, set)
+			}
+			continue
+		}
+		i, ok := best[f.App]
+		if !ok {
+			best[f.App] = len(sets)
+			sets = append(sets, set)
+		} else if better(f.Level, sets[i][0].Level) {
+			sets[i] = set
+		}
+	}
+	return sets
+}
+
+// copyRecords writes the records of m accepted by keep to w, the lines
+// before the first record of a file are reported and skipped.
+func copyRecords(w io.Writer, m *reader.Merger, keep func(rec *reader.Record) bool, write func(bw *bufio.Writer, rec *reader.Record)) error {
+	bw := bufio.NewWriterSize(w, 64<<10)
+	for {
+		rec, err := m.Next()
+		if err == io.EOF {
+			break
+		}
+		var serr *reader.SyntaxError
+		if errors.As(err, &serr) {
+			fmt.Fprintln(os.Stderr, "jlog:", err)
+			continue
+		}
+		if err != nil {
+			_ = bw.Flush()
+			return err
+		}
+		if keep == nil || keep(&rec) {
+			write(bw, &rec)
+		}
+	}
+	return bw.Flush()
+}
+
+// writeText writes rec in the text format of jlog, its time in the
+// -time-format the logs were written with.
+func (f *readFlags) writeText(bw *bufio.Writer, rec *reader.Record) {
+	var tmp [64]byte
+	switch f.timeFormat {
+	case jlog.TimeFormatGlog:
+		_, _ = bw.Write(rec.Time.AppendFormat(tmp[:0], "20060102 15:04:05.000000"))
+	case jlog.TimeFormatUnixMilli:
+		_, _ = bw.Write(strconv.AppendInt(tmp[:0], rec.Time.UnixNano()/int64(time.Millisecond), 10))
+	case jlog.TimeFormatUnixNano:
+		_, _ = bw.Write(strconv.AppendInt(tmp[:0], rec.Time.UnixNano(), 10))
+	default:
+		_, _ = bw.Write(rec.Time.AppendFormat(tmp[:0], f.timeFormat))
+	}
+	_, _ = bw.WriteString(" [")
+	_ = bw.WriteByte(jlog.LevelFlags[rec.Level])
+	_, _ = bw.WriteString("]:")
+	if rec.Prefix != "" {
+		_ = bw.WriteByte('[')
+		_, _ = bw.WriteString(rec.Prefix)
+		_ = bw.WriteByte(']')
+	}
+	_, _ = bw.WriteString(rec.Body)
+	if rec.File != "" {
+		_, _ = bw.WriteString(" [")
+		_, _ = bw.WriteString(rec.File)
+		_ = bw.WriteByte(':')
+		_, _ = bw.WriteString(strconv.Itoa(rec.Line))
+		_ = bw.WriteByte(']')
+	}
+	_ = bw.WriteByte('\n')
+}
+
+func runMerge(args []string) error {
+	var rf readFlags
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	rf.register(fs)
+	_ = fs.Parse(args)
+	opts, err := rf.options()
+	if err != nil {
+		return err
+	}
+	m, err := openPaths(fs.Args(), jlog.DEBUG, opts)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return copyRecords(os.Stdout, m, nil, rf.writeText)
+}
@@ -0,0 +1,209 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/jlogtest"
+	"github.com/tiger-game/jlog/reader"
+)
+
+type closeLogger interface {
+	jlog.Logger
+	Outputf(lv jlog.Level, prefix string, depth int, format string, args ...interface{})
+	Close()
+}
+
+// clockLogger logs to dir in UTC at the time of clock.
+func clockLogger(dir string, clock jlog.Clock) closeLogger {
+	return jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogClock(clock),
+		jlog.LogTimeZone(time.UTC), jlog.LogSync(true))
+}
+
+// symlink is the logName.ext symlink of the test binary in dir.
+func symlink(dir, ext string) string {
+	return filepath.Join(dir, jlog.WithoutExt(filepath.Base(os.Args[0]))+"."+ext)
+}
+
+// follow tails path with a poll per step, returning what was copied.
+func follow(t *testing.T, path string, steps ...func()) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	tl := tailer{out: bufio.NewWriter(&out), br: bufio.NewReader(f)}
+	err = tl.follow(f, path, func() bool {
+		if len(steps) == 0 {
+			return false
+		}
+		steps[0]()
+		steps = steps[1:]
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestTailFollow(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlogtest.NewClock(time.Date(2026, 10, 18, 10, 59, 0, 0, time.UTC))
+	l := clockLogger(dir, clock)
+	defer l.Close()
+	l.Infof("first")
+
+	got := follow(t, symlink(dir, "inf"),
+		func() { l.Infof("second") },
+		func() {
+			clock.Set(time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC))
+			l.Infof("third")
+		},
+		func() {
+			clock.Set(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+			l.Infof("fourth")
+			l.Infof("fifth")
+		},
+	)
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	want := []string{"first", "second", "third", "fourth", "fifth"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q, want the lines of %q", lines, want)
+	}
+	for i, line := range lines {
+		rec, err := reader.ParseLine(line, reader.Location(time.UTC))
+		if err != nil || rec.Body != want[i] {
+			t.Fatalf("line %d = %q, want %q", i, line, want[i])
+		}
+	}
+}
+
+func TestTailPartialLine(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "app.inf")
+	write := func(name, text string) {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(text), 0664); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.Remove(link)
+		if err := os.Symlink(path, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.2026101810.0.inf.log", "one\ntwo")
+	got := follow(t, link, func() { write("app.2026101811.0.inf.log", "three\n") })
+	if got != "one\ntwo\nthree\n" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestGrepFilters(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlogtest.NewClock(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+	l := clockLogger(dir, clock)
+	for _, r := range []struct {
+		lv     jlog.Level
+		prefix string
+		body   string
+	}{
+		{jlog.INFO, "", "boot"},
+		{jlog.WARN, "net", "slow peer"},
+		{jlog.ERROR, "db", "lost conn"},
+		{jlog.INFO, "net", "peer up"},
+		{jlog.ERROR, "net", "peer down"},
+	} {
+		l.Outputf(r.lv, r.prefix, 0, "%s", r.body)
+		clock.Add(30 * time.Minute)
+	}
+	l.Close()
+
+	since := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	until := time.Date(2026, 10, 18, 10, 30, 0, 0, time.UTC)
+	for _, c := range []struct {
+		name string
+		g    grepFilter
+		want string
+	}{
+		{"all", grepFilter{}, "boot,slow peer,lost conn,peer up,peer down"},
+		{"pattern", grepFilter{re: regexp.MustCompile(`^peer`)}, "peer up,peer down"},
+		{"level", grepFilter{level: jlog.WARN}, "slow peer,lost conn,peer down"},
+		{"prefix", grepFilter{prefix: "net"}, "slow peer,peer up,peer down"},
+		{"since", grepFilter{since: since}, "slow peer,lost conn,peer up,peer down"},
+		{"until", grepFilter{until: until}, "boot,slow peer,lost conn"},
+		{"range", grepFilter{level: jlog.WARN, prefix: "net", since: since, until: until}, "slow peer"},
+	} {
+		if c.g.re == nil {
+			c.g.re = regexp.MustCompile("")
+		}
+		m, err := openPaths([]string{dir}, c.g.level, []reader.Option{reader.Location(time.UTC)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		err = copyRecords(&out, m, c.g.keep, func(bw *bufio.Writer, rec *reader.Record) {
+			_, _ = bw.WriteString(rec.Body + ",")
+		})
+		_ = m.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSuffix(out.String(), ","); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestLevelSets(t *testing.T) {
+	var files []reader.File
+	for _, f := range []struct {
+		app string
+		lv  jlog.Level
+	}{{"a", jlog.DEBUG}, {"a", jlog.INFO}, {"a", jlog.WARN}, {"a", jlog.ERROR}, {"b", jlog.WARN}, {"b", jlog.ERROR}} {
+		files = append(files, reader.File{Path: f.app + "." + jlog.LevelExtNames[f.lv], App: f.app, Level: f.lv})
+	}
+	for lv, want := range map[jlog.Level]string{
+		jlog.DEBUG: "a.dbg,a.inf,b.wrn",
+		jlog.INFO:  "a.inf,b.wrn",
+		jlog.WARN:  "a.wrn,b.wrn",
+		jlog.ERROR: "a.err,b.err",
+	} {
+		var got []string
+		for _, set := range levelSets(files, lv) {
+			for _, f := range set {
+				got = append(got, f.Path)
+			}
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != want {
+			t.Errorf("levelSets(%s) = %q, want %s", jlog.LevelNames[lv], got, want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	for _, s := range []string{"2026-10-18T09:30:00Z", "2026-10-18 09:30:00", "2026-10-18 09:30", "20261018 09:30:00"} {
+		if got, err := parseTime(s, time.UTC); err != nil || !got.Equal(want) {
+			t.Errorf("parseTime(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := parseTime("yesterday", time.UTC); err == nil {
+		t.Error("parseTime(yesterday) succeeded")
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"time"
+)
+
+// pollInterval is how often tail -f looks for new lines and for the
+// rotation of the logName.ext symlink.
+const pollInterval = 250 * time.Millisecond
+
+func runTail(args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	follow := fs.Bool("f", false, "follow the file, and the symlink across the rotations")
+	n := fs.Int("n", 10, "the number of the last lines")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("want a file, the logName.ext symlink to follow the rotations")
+	}
+	path := fs.Arg(0)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	off, err := tailOffset(f, *n)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(off, io.SeekStart); err != nil {
+		return err
+	}
+
+	out := bufio.NewWriterSize(os.Stdout, 64<<10)
+	if !*follow {
+		if _, err = io.Copy(out, f); err != nil {
+			return err
+		}
+		return out.Flush()
+	}
+
+	t := tailer{out: out, br: bufio.NewReaderSize(f, 64<<10)}
+	return t.follow(f, path, func() bool {
+		time.Sleep(pollInterval)
+		return true
+	})
+}
+
+// tailer copies the complete lines of a file, a line still being written
+// is kept until its end comes.
+type tailer struct {
+	out     *bufio.Writer
+	br      *bufio.Reader
+	partial []byte
+}
+
+// follow copies the lines of f, then of the files path points to after it,
+// calling wait between the polls until it reports false.
+func (t *tailer) follow(f *os.File, path string, wait func() bool) error {
+	defer func() { _ = f.Close() }()
+	for {
+		if err := t.copyLines(); err != nil {
+			return err
+		}
+		if err := t.out.Flush(); err != nil {
+			return err
+		}
+
+		// jlog points the symlink to the new file once the old one is
+		// closed, what is left of the old one is read first.
+		cur, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		next, err := os.Stat(path)
+		if err != nil || os.SameFile(cur, next) {
+			if !wait() {
+				return nil
+			}
+			continue
+		}
+		nf, err := os.Open(path)
+		if err != nil {
+			if !wait() {
+				return nil
+			}
+			continue
+		}
+		if err = t.copyLines(); err != nil {
+			_ = nf.Close()
+			return err
+		}
+		if len(t.partial) > 0 {
+			// the old file ended without a line break.
+			_, _ = t.out.Write(t.partial)
+			_ = t.out.WriteByte('\n')
+			t.partial = t.partial[:0]
+		}
+		_ = f.Close()
+		f = nf
+		t.br.Reset(f)
+	}
+}
+
+func (t *tailer) copyLines() error {
+	for {
+		line, err := t.br.ReadSlice('\n')
+		switch {
+		case err == nil:
+			_, _ = t.out.Write(t.partial)
+			_, _ = t.out.Write(line)
+			t.partial = t.partial[:0]
+		case err == bufio.ErrBufferFull:
+			t.partial = append(t.partial, line...)
+		case err == io.EOF:
+			t.partial = append(t.partial, line...)
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// tailOffset returns the offset of the last n lines of f, scanning it
+// backwards from its end.
+func tailOffset(f *os.File, n int) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	end := info.Size()
+	if n <= 0 {
+		return end, nil
+	}
+
+	var chunk [32 << 10]byte
+	for off := end; off > 0; {
+		size := int64(len(chunk))
+		if off < size {
+			size = off
+		}
+		off -= size
+		if _, err = f.ReadAt(chunk[:size], off); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := size - 1; i >= 0; i-- {
+			// the break ending the last line does not count.
+			if chunk[i] != '\n' || off+i == end-1 {
+				continue
+			}
+			if n--; n == 0 {
+				return off + i + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
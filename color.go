@@ -0,0 +1,73 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"io"
+	"os"
+)
+
+type colorMode int8
+
+const (
+	colorAuto colorMode = iota // only when the console is a terminal
+	colorOn
+	colorOff
+)
+
+const colorReset = "\x1b[0m"
+
+// levelColors are the ANSI colors of the [L] flag and of the message.
+var levelColors = [MaxLevel]struct{ flag, msg string }{
+	DEBUG: {"\x1b[1;90m", "\x1b[90m"},
+	INFO:  {"\x1b[1;32m", ""},
+	WARN:  {"\x1b[1;33m", "\x1b[33m"},
+	ERROR: {"\x1b[1;31m", "\x1b[31m"},
+}
+
+// _Use reports whether records written to the console w are colored.
+func (m colorMode) _Use(w io.Writer) bool {
+	switch m {
+	case colorOn:
+		return true
+	case colorOff:
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && isTerminal(f)
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// appendColored appends the rendered line of buf to dst with the [L] flag
+// and the message wrapped in the colors of its level.
+func appendColored(dst []byte, buf *Buffer) []byte {
+	line := buf.Bytes()
+	flag := buf.flag
+	if buf.lv < MinLevel || buf.lv >= MaxLevel || flag+4 > len(line) || line[flag] != '[' {
+		return append(dst, line...)
+	}
+	c := levelColors[buf.lv]
+
+	end := len(line)
+	if line[end-1] == '\n' {
+		end--
+	}
+	dst = append(dst, line[:flag]...)
+	dst = append(dst, c.flag...)
+	dst = append(dst, line[flag:flag+3]...)
+	dst = append(dst, colorReset...)
+	dst = append(dst, line[flag+3])
+	if c.msg == "" {
+		return append(dst, line[flag+4:]...)
+	}
+	dst = append(dst, c.msg...)
+	dst = append(dst, line[flag+4:end]...)
+	dst = append(dst, colorReset...)
+	return append(dst, line[end:]...)
+}
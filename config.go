@@ -0,0 +1,494 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Config is the declarative form of the Option functions, to load the
+// logger setup from a JSON or YAML file, see LoadConfig, and the environment.
+type Config struct {
+	Dir          string            `json:"dir,omitempty" yaml:"dir,omitempty"`
+	Level        string            `json:"level,omitempty" yaml:"level,omitempty"`               // LogLevel, a ceiling: the highest level logged, INFO by default
+	PrefixLevels map[string]string `json:"prefixLevels,omitempty" yaml:"prefixLevels,omitempty"` // the ceilings of the prefixes, as Level
+	Rotation     RotationConfig    `json:"rotation" yaml:"rotation"`
+	Retention    RetentionConfig   `json:"retention" yaml:"retention"`
+	Encoder      string            `json:"encoder,omitempty" yaml:"encoder,omitempty"` // only "text" for now
+	Console      bool              `json:"console,omitempty" yaml:"console,omitempty"`
+	Caller       bool              `json:"caller,omitempty" yaml:"caller,omitempty"`
+	TimeFormat   string            `json:"timeFormat,omitempty" yaml:"timeFormat,omitempty"`
+	TimeZone     string            `json:"timeZone,omitempty" yaml:"timeZone,omitempty"`   // "UTC", "Local" or an IANA name
+	Multiline    string            `json:"multiline,omitempty" yaml:"multiline,omitempty"` // "raw", "escape" or "indent"
+	Sampling     *SamplingConfig   `json:"sampling,omitempty" yaml:"sampling,omitempty"`
+	Sinks        []SinkConfig      `json:"sinks,omitempty" yaml:"sinks,omitempty"`
+}
+
+// SamplingConfig is the LogSampling of a Config.
+type SamplingConfig struct {
+	Initial    int `json:"initial,omitempty" yaml:"initial,omitempty"`
+	Thereafter int `json:"thereafter,omitempty" yaml:"thereafter,omitempty"`
+}
+
+// RotationConfig is the LogMaxSize of a Config, the files rotate hourly.
+type RotationConfig struct {
+	MaxSize int `json:"maxSize,omitempty" yaml:"maxSize,omitempty"` // bytes, MaxSize when zero
+}
+
+// RetentionConfig is the LogRetention of a Config.
+type RetentionConfig struct {
+	MaxAge   string `json:"maxAge,omitempty" yaml:"maxAge,omitempty"` // a time.Duration such as "168h"
+	MaxFiles int    `json:"maxFiles,omitempty" yaml:"maxFiles,omitempty"`
+}
+
+// SinkConfig is a LogSink of a Config, Path is "stdout", "stderr" or a file
+// the records are appended to.
+type SinkConfig struct {
+	Level string `json:"level" yaml:"level"` // a minimum unlike Config.Level: the lowest level written, with every one above
+	Path  string `json:"path" yaml:"path"`
+}
+
+// ConfigError reports the invalid field of a Config, or environment variable.
+type ConfigError struct {
+	Field string
+	Err   error
+}
+
+func (e *ConfigError) Error() string { return "jlog: config " + e.Field + ": " + e.Err.Error() }
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+func configErr(field string, format string, args ...interface{}) error {
+	return &ConfigError{Field: field, Err: fmt.Errorf(format, args...)}
+}
+
+// ParseLevel returns the level named s, case insensitive.
+func ParseLevel(s string) (Level, error) {
+	if lv, ok := NameLevels[strings.ToUpper(s)]; ok {
+		return lv, nil
+	}
+	return Level(-1), fmt.Errorf("unknown level %q", s)
+}
+
+// parseCeiling parses the level of level and prefixLevels, the highest one
+// logged. DEBUG is refused, as a ceiling it would only let the DEBUG records
+// through, which only the dev builds write.
+func parseCeiling(s string) (Level, error) {
+	lv, err := ParseLevel(s)
+	if err == nil && lv == DEBUG {
+		return lv, errors.New("DEBUG would drop every other level, ERROR logs them all")
+	}
+	return lv, err
+}
+
+// LoadConfig reads the config file at path, YAML for the .yaml and .yml
+// files and JSON for the others.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&c)
+		if err == io.EOF {
+			err = nil // an empty file
+		}
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&c)
+	}
+	if err != nil {
+		return c, decodeErr(path, data, err)
+	}
+	return c, nil
+}
+
+// decodeErr reports the decode error of the config file at path as a
+// *ConfigError naming the field it is about, the syntax errors name none.
+func decodeErr(path string, data []byte, err error) error {
+	var (
+		yamlErr *yaml.TypeError
+		jsonErr *json.UnmarshalTypeError
+		field   string
+	)
+	switch {
+	case errors.As(err, &yamlErr) && len(yamlErr.Errors) > 0:
+		// "line N: ...", the first field wrong.
+		var line int
+		_, _ = fmt.Sscanf(yamlErr.Errors[0], "line %d:", &line)
+		var doc yaml.Node
+		if yaml.Unmarshal(data, &doc) == nil {
+			field = yamlField(&doc, line)
+		}
+		err = errors.New(yamlErr.Errors[0])
+	case errors.As(err, &jsonErr):
+		field = jsonErr.Field
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ = strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+	}
+	if field == "" {
+		return fmt.Errorf("jlog: config %s: %v", path, err)
+	}
+	return &ConfigError{Field: field, Err: fmt.Errorf("%s: %v", path, err)}
+}
+
+// yamlField returns the path of the field of doc at line, such as
+// "sinks[1].level", the one of the last key starting at or before it.
+func yamlField(doc *yaml.Node, line int) string {
+	var field string
+	for n := doc; n != nil; {
+		var next *yaml.Node
+		if n.Style&yaml.FlowStyle != 0 {
+			break // [a, b] or {a: b} is reported at its start
+		}
+		switch n.Kind {
+		case yaml.DocumentNode:
+			if len(n.Content) > 0 {
+				next = n.Content[0]
+			}
+		case yaml.MappingNode:
+			var key string
+			for i := 0; i+1 < len(n.Content) && n.Content[i].Line <= line; i += 2 {
+				key, next = n.Content[i].Value, n.Content[i+1]
+			}
+			if next != nil && field != "" {
+				field += "."
+			}
+			field += key
+		case yaml.SequenceNode:
+			idx := -1
+			for i, item := range n.Content {
+				if item.Line <= line {
+					idx, next = i, item
+				}
+			}
+			if idx >= 0 {
+				field += "[" + strconv.Itoa(idx) + "]"
+			}
+		}
+		n = next
+	}
+	return field
+}
+
+// NewFromConfig validates c and returns the logger of its options.
+func NewFromConfig(c Config) (*logger, error) {
+	opts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	return NewLogger(opts...), nil
+}
+
+// Validate reports the first invalid field of c as a *ConfigError.
+func (c *Config) Validate() error {
+	if c.Dir == "" && !c.Console {
+		return configErr("dir", "required unless console is set")
+	}
+	if c.Level != "" {
+		if _, err := parseCeiling(c.Level); err != nil {
+			return &ConfigError{Field: "level", Err: err}
+		}
+	}
+	for _, prefix := range sortedKeys(c.PrefixLevels) {
+		if prefix == "" {
+			return configErr("prefixLevels", "empty prefix")
+		}
+		if _, err := parseCeiling(c.PrefixLevels[prefix]); err != nil {
+			return &ConfigError{Field: "prefixLevels." + prefix, Err: err}
+		}
+	}
+	if c.Rotation.MaxSize < 0 {
+		return configErr("rotation.maxSize", "negative size %d", c.Rotation.MaxSize)
+	}
+	if c.Retention.MaxAge != "" {
+		if d, err := time.ParseDuration(c.Retention.MaxAge); err != nil {
+			return &ConfigError{Field: "retention.maxAge", Err: err}
+		} else if d < 0 {
+			return configErr("retention.maxAge", "negative duration %s", d)
+		}
+	}
+	if c.Retention.MaxFiles < 0 {
+		return configErr("retention.maxFiles", "negative count %d", c.Retention.MaxFiles)
+	}
+	if c.Encoder != "" && c.Encoder != "text" {
+		return configErr("encoder", "unsupported encoder %q", c.Encoder)
+	}
+	if c.Sampling != nil && c.Sampling.Initial < 0 {
+		return configErr("sampling.initial", "negative count %d", c.Sampling.Initial)
+	}
+	if c.Sampling != nil && c.Sampling.Thereafter < 0 {
+		return configErr("sampling.thereafter", "negative count %d", c.Sampling.Thereafter)
+	}
+	if c.TimeZone != "" {
+		if _, err := time.LoadLocation(c.TimeZone); err != nil {
+			return &ConfigError{Field: "timeZone", Err: err}
+		}
+	}
+	switch c.Multiline {
+	case "", MultilineRaw, MultilineEscape, MultilineIndent:
+	default:
+		return configErr("multiline", "unknown mode %q", c.Multiline)
+	}
+	for i, s := range c.Sinks {
+		field := "sinks[" + strconv.Itoa(i) + "]"
+		if _, err := ParseLevel(s.Level); err != nil {
+			return &ConfigError{Field: field + ".level", Err: err}
+		}
+		if s.Path == "" {
+			return configErr(field+".path", "empty path")
+		}
+	}
+	return nil
+}
+
+// Options validates c and maps it onto the Option functions, the sink files
+// are opened here and closed with the logger.
+func (c *Config) Options() ([]Option, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var opts []Option
+	if c.Dir != "" {
+		opts = append(opts, LogDir(c.Dir))
+	}
+	if c.Level != "" {
+		lv, _ := ParseLevel(c.Level)
+		opts = append(opts, LogLevel(lv))
+	}
+	if len(c.PrefixLevels) > 0 {
+		levels := make(map[string]Level, len(c.PrefixLevels))
+		for prefix, name := range c.PrefixLevels {
+			levels[prefix], _ = ParseLevel(name)
+		}
+		opts = append(opts, LogPrefixLevels(levels))
+	}
+	if c.Rotation.MaxSize > 0 {
+		opts = append(opts, LogMaxSize(c.Rotation.MaxSize))
+	}
+	if c.Retention.MaxAge != "" || c.Retention.MaxFiles > 0 {
+		maxAge, _ := time.ParseDuration(c.Retention.MaxAge)
+		opts = append(opts, LogRetention(maxAge, c.Retention.MaxFiles))
+	}
+	if c.Console {
+		opts = append(opts, LogConsole(true))
+	}
+	if c.Caller {
+		opts = append(opts, LogCaller(true))
+	}
+	if c.TimeFormat != "" {
+		opts = append(opts, LogTimeFormat(c.TimeFormat))
+	}
+	if c.TimeZone != "" {
+		loc, _ := time.LoadLocation(c.TimeZone)
+		opts = append(opts, LogTimeZone(loc))
+	}
+	if c.Multiline != "" {
+		opts = append(opts, LogMultiline(c.Multiline))
+	}
+
+	if c.Sampling != nil && c.Sampling.Initial > 0 {
+		opts = append(opts, LogSampling(c.Sampling.Initial, c.Sampling.Thereafter))
+	}
+
+	outs, closers, err := c._OpenSinks(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, closer := range closers {
+		opts = append(opts, _LogCloser(closer))
+	}
+	for lv := range outs {
+		for _, w := range outs[lv] {
+			opts = append(opts, LogSink(Level(lv), w))
+		}
+	}
+	return opts, nil
+}
+
+// _OpenSinks opens the sinks of the validated c by level, the files of open
+// are reused.
+func (c *Config) _OpenSinks(open map[string]*os.File) (outs [MaxLevel][]io.Writer, closers []io.Closer, err error) {
+	for i, s := range c.Sinks {
+		lv, _ := ParseLevel(s.Level)
+		w, closer, err := openSink(s.Path, open)
+		if err != nil {
+			for _, closer := range closers {
+				if f, ok := closer.(*os.File); !ok || open[f.Name()] != f {
+					_ = closer.Close()
+				}
+			}
+			return outs, nil, &ConfigError{Field: "sinks[" + strconv.Itoa(i) + "].path", Err: err}
+		}
+		if closer != nil && !hasCloser(closers, closer) {
+			closers = append(closers, closer)
+		}
+		outs[lv] = append(outs[lv], w)
+	}
+	return outs, closers, nil
+}
+
+func openSink(path string, open map[string]*os.File) (io.Writer, io.Closer, error) {
+	switch path {
+	case "stdout":
+		return os.Stdout, nil, nil
+	case "stderr":
+		return os.Stderr, nil, nil
+	}
+	if f, ok := open[path]; ok {
+		return f, f, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0664)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f, nil
+}
+
+func hasCloser(closers []io.Closer, c io.Closer) bool {
+	for _, x := range closers {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyEnv overlays the JLOG_ environment variables on c:
+//
+//	JLOG_DIR, JLOG_LEVEL, JLOG_ENCODER, JLOG_TIME_FORMAT, JLOG_TIME_ZONE
+//	JLOG_MULTILINE
+//	JLOG_CONSOLE, JLOG_CALLER              true or false
+//	JLOG_MAX_SIZE, JLOG_MAX_FILES          integers
+//	JLOG_SAMPLING_INITIAL                  integers
+//	JLOG_SAMPLING_THEREAFTER               integers
+//	JLOG_MAX_AGE                           a time.Duration
+//	JLOG_PREFIX_LEVELS                     prefix=LEVEL,...
+//	JLOG_SINKS                             LEVEL=path,...
+//
+// A malformed variable is reported as a *ConfigError naming it.
+func (c *Config) ApplyEnv() error {
+	for _, v := range []struct {
+		name string
+		dst  *string
+	}{
+		{"JLOG_DIR", &c.Dir},
+		{"JLOG_LEVEL", &c.Level},
+		{"JLOG_ENCODER", &c.Encoder},
+		{"JLOG_TIME_FORMAT", &c.TimeFormat},
+		{"JLOG_TIME_ZONE", &c.TimeZone},
+		{"JLOG_MULTILINE", &c.Multiline},
+		{"JLOG_MAX_AGE", &c.Retention.MaxAge},
+	} {
+		if s, ok := os.LookupEnv(v.name); ok {
+			*v.dst = s
+		}
+	}
+	for _, v := range []struct {
+		name string
+		dst  *bool
+	}{
+		{"JLOG_CONSOLE", &c.Console},
+		{"JLOG_CALLER", &c.Caller},
+	} {
+		if s, ok := os.LookupEnv(v.name); ok {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return &ConfigError{Field: v.name, Err: err}
+			}
+			*v.dst = b
+		}
+	}
+	// a copy, c.Sampling may be shared with the caller.
+	sampling := new(SamplingConfig)
+	if c.Sampling != nil {
+		*sampling = *c.Sampling
+	}
+	for _, v := range []struct {
+		name string
+		dst  *int
+	}{
+		{"JLOG_MAX_SIZE", &c.Rotation.MaxSize},
+		{"JLOG_MAX_FILES", &c.Retention.MaxFiles},
+		{"JLOG_SAMPLING_INITIAL", &sampling.Initial},
+		{"JLOG_SAMPLING_THEREAFTER", &sampling.Thereafter},
+	} {
+		if s, ok := os.LookupEnv(v.name); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return &ConfigError{Field: v.name, Err: err}
+			}
+			*v.dst = n
+			if v.dst == &sampling.Initial || v.dst == &sampling.Thereafter {
+				c.Sampling = sampling
+			}
+		}
+	}
+	if s, ok := os.LookupEnv("JLOG_PREFIX_LEVELS"); ok {
+		pairs, err := parsePairs(s)
+		if err != nil {
+			return &ConfigError{Field: "JLOG_PREFIX_LEVELS", Err: err}
+		}
+		c.PrefixLevels = make(map[string]string, len(pairs))
+		for _, p := range pairs {
+			c.PrefixLevels[p[0]] = p[1]
+		}
+	}
+	if s, ok := os.LookupEnv("JLOG_SINKS"); ok {
+		pairs, err := parsePairs(s)
+		if err != nil {
+			return &ConfigError{Field: "JLOG_SINKS", Err: err}
+		}
+		// a new slice, c.Sinks may be shared with the caller.
+		c.Sinks = make([]SinkConfig, 0, len(pairs))
+		for _, p := range pairs {
+			c.Sinks = append(c.Sinks, SinkConfig{Level: p[0], Path: p[1]})
+		}
+	}
+	return nil
+}
+
+// parsePairs splits "k=v,k=v", blanks around the items are ignored.
+func parsePairs(s string) ([][2]string, error) {
+	var pairs [][2]string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		i := strings.IndexByte(item, '=')
+		if i <= 0 {
+			return nil, errors.New("malformed item " + strconv.Quote(item) + ", want key=value")
+		}
+		pairs = append(pairs, [2]string{strings.TrimSpace(item[:i]), strings.TrimSpace(item[i+1:])})
+	}
+	return pairs, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// consoleStream is the console target of a level, unlike a FileStream it is
+// never rotated nor closed by the logger.
+type consoleStream struct {
+	writer *bufio.Writer
+	color  bool
+}
+
+func (c *consoleStream) IsWriter() bool { return c.writer != nil }
+
+func (c *consoleStream) _Init(out io.Writer, color colorMode) {
+	c.writer = bufio.NewWriter(out)
+	c.color = color._Use(out)
+}
+
+func (c *consoleStream) Flush() error {
+	if !c.IsWriter() {
+		return nil
+	}
+	return c.writer.Flush()
+}
+
+// _InitConsole opens the console streams of the enabled levels, ERROR goes
+// to stderr and the others to stdout unless LogConsoleWriter chose a target.
+func (l *logFile) _InitConsole() {
+	for lv := range l.console {
+		if !l._Check(Level(lv)) || l.console[lv].IsWriter() {
+			continue
+		}
+		out := l.consoleOut[lv]
+		if out == nil {
+			out = os.Stdout
+			if lv == int(ERROR) {
+				out = os.Stderr
+			}
+		}
+		l.console[lv]._Init(out, l.color)
+	}
+}
+
+// _WriteConsole writes the lines of level lv to its console stream. Console
+// errors are dropped, a closed stdout must not stop the file output.
+func (l *logFile) _WriteConsole(lv Level, lines []*Buffer) {
+	c := &l.console[lv]
+	for _, buf := range lines {
+		if buf.lv != lv {
+			continue
+		}
+		if !c.color {
+			_, _ = c.writer.Write(buf.Bytes())
+			continue
+		}
+		l.colored = appendColored(l.colored[:0], buf)
+		_, _ = c.writer.Write(l.colored)
+	}
+}
+
+// _WriteSink writes the lines of level lv and above to a LogSink target, its
+// errors are dropped like the console ones.
+func (l *logFile) _WriteSink(s *consoleStream, lv Level, lines []*Buffer) {
+	for _, buf := range lines {
+		if buf.lv >= lv {
+			_, _ = s.writer.Write(buf.Bytes())
+		}
+	}
+}
@@ -4,62 +4,157 @@
 
 package jlog
 
-var gLog *logger
-var stdLog *logger
+import (
+	"context"
+	"sync/atomic"
+	"unsafe"
+)
+
+// gLog and stdLog hold a *logger and are only accessed atomically,
+// so they can be swapped while other goroutines are logging.
+var (
+	gLog   unsafe.Pointer
+	stdLog unsafe.Pointer
+)
+
+func _Load(p *unsafe.Pointer) *logger { return (*logger)(atomic.LoadPointer(p)) }
+
+// _Swap installs l and drains and closes the logger it replaces, the
+// records still logged to that one by the goroutines which loaded it before
+// the swap go to l.
+func _Swap(p *unsafe.Pointer, l *logger) {
+	if old := (*logger)(atomic.SwapPointer(p, unsafe.Pointer(l))); old != nil && old != l {
+		if l != nil && !l._WriterDone() { // a closed l would hand them back
+			atomic.StorePointer(&old.next, unsafe.Pointer(l))
+		}
+		old.Close()
+	}
+}
+
+// _StdLogger returns stdLog, creating it on first use so that errors
+// reported before StdLogInit are not lost.
+func _StdLogger() *logger {
+	if l := _Load(&stdLog); l != nil {
+		return l
+	}
+	l := _NewStdLog()
+	if atomic.CompareAndSwapPointer(&stdLog, nil, unsafe.Pointer(l)) {
+		return l
+	}
+	l.Close()
+	return _Load(&stdLog)
+}
+
+// Default
+// fetch the logger used by the package level functions, it falls back to
+// stdLog until SetDefault or GLogInit is called.
+func Default() *logger {
+	if l := _Load(&gLog); l != nil {
+		return l
+	}
+	return _StdLogger()
+}
+
+// SetDefault
+// replace the logger used by the package level functions, the previous one
+// is drained and closed.
+func SetDefault(l *logger) { _Swap(&gLog, l) }
 
 // GLog
 // fetch gLog
-func GLog() Logger        { return gLog }
-func _StdLog() Logger     { return stdLog }
-func DebugStdLog() Logger { return stdLog }
+func GLog() Logger        { return Default() }
+func _StdLog() Logger     { return _StdLogger() }
+func DebugStdLog() Logger { return _StdLogger() }
 
 func CloseGLog() {
-	gLog.Close()
+	_Load(&gLog).Close()
 	CloseStdLog()
 }
 
+// CloseGLogContext
+// close gLog and stdLog, giving up waiting once ctx is done
+func CloseGLogContext(ctx context.Context) error {
+	if err := _Load(&gLog).CloseContext(ctx); err != nil {
+		return err
+	}
+	return _Load(&stdLog).CloseContext(ctx)
+}
+
 func CloseStdLog() {
-	stdLog.Close()
+	_Load(&stdLog).Close()
+}
+
+// Sync
+// block until every record queued to gLog and stdLog is persisted
+func Sync() error {
+	if err := _Load(&gLog).Sync(); err != nil {
+		return err
+	}
+	return _Load(&stdLog).Sync()
 }
 
 // Debug
 // global gLog for debug
-func Debug(args ...interface{}) { gLog.Output(DEBUG, "GLog", 0, args...) }
+func Debug(args ...interface{}) { Default().Output(DEBUG, "GLog", 0, args...) }
 
 // Info
 // global gLog for info
-func Info(args ...interface{}) { gLog.Output(INFO, "GLog", 0, args...) }
+func Info(args ...interface{}) { Default().Output(INFO, "GLog", 0, args...) }
 
 // Warn
 // global gLog for warn
-func Warn(args ...interface{}) { gLog.Output(WARN, "GLog", 0, args...) }
+func Warn(args ...interface{}) { Default().Output(WARN, "GLog", 0, args...) }
 
 // Error
 // global gLog for error
-func Error(args ...interface{}) { gLog.Output(ERROR, "GLog", 0, args...) }
+func Error(args ...interface{}) { Default().Output(ERROR, "GLog", 0, args...) }
 
 // Debugf
 // global gLog for debug
 func Debugf(format string, args ...interface{}) {
-	gLog.Outputf(DEBUG, "GLog", 0, format, args...)
+	Default().Outputf(DEBUG, "GLog", 0, format, args...)
 }
 
 // Infof
 // global gLog for info
 func Infof(format string, args ...interface{}) {
-	gLog.Outputf(INFO, "GLog", 0, format, args...)
+	Default().Outputf(INFO, "GLog", 0, format, args...)
 }
 
 // Warnf
 // global gLog for warn
 func Warnf(format string, args ...interface{}) {
-	gLog.Outputf(WARN, "GLog", 0, format, args...)
+	Default().Outputf(WARN, "GLog", 0, format, args...)
 }
 
 // Errorf
 // global gLog for error
 func Errorf(format string, args ...interface{}) {
-	gLog.Outputf(ERROR, "GLog", 0, format, args...)
+	Default().Outputf(ERROR, "GLog", 0, format, args...)
+}
+
+// Debugw
+// global gLog for debug with typed fields
+func Debugw(msg string, fields ...Field) {
+	Default().OutputFields(DEBUG, "GLog", 0, msg, fields...)
+}
+
+// Infow
+// global gLog for info with typed fields
+func Infow(msg string, fields ...Field) {
+	Default().OutputFields(INFO, "GLog", 0, msg, fields...)
+}
+
+// Warnw
+// global gLog for warn with typed fields
+func Warnw(msg string, fields ...Field) {
+	Default().OutputFields(WARN, "GLog", 0, msg, fields...)
+}
+
+// Errorw
+// global gLog for error with typed fields
+func Errorw(msg string, fields ...Field) {
+	Default().OutputFields(ERROR, "GLog", 0, msg, fields...)
 }
 
 type CustomLogger struct {
@@ -68,63 +163,101 @@ type CustomLogger struct {
 	level  Level
 }
 
+// _Logger returns the bound logger, or the current default one when the
+// CustomLogger was created through NewLogByPrefix.
+func (cl *CustomLogger) _Logger() *logger {
+	if cl.logger != nil {
+		return cl.logger
+	}
+	return Default()
+}
+
 func (cl *CustomLogger) _ControlFlag(lv Level) bool {
-	return cl.logger != nil && cl.level >= lv
+	return cl.level >= lv
+}
+
+func (cl *CustomLogger) Enabled(lv Level) bool {
+	return cl._ControlFlag(lv) && cl._Logger()._Enabled(lv, cl.prefix)
 }
 
+func (cl *CustomLogger) Sync() error { return cl._Logger().Sync() }
+
 func (cl *CustomLogger) Debug(args ...interface{}) {
 	if !cl._ControlFlag(DEBUG) {
 		return
 	}
-	cl.Output(DEBUG, cl.prefix, 0, args...)
+	cl._Logger().Output(DEBUG, cl.prefix, 0, args...)
 }
 func (cl *CustomLogger) Info(args ...interface{}) {
 	if !cl._ControlFlag(INFO) {
 		return
 	}
-	cl.Output(INFO, cl.prefix, 0, args...)
+	cl._Logger().Output(INFO, cl.prefix, 0, args...)
 }
 func (cl *CustomLogger) Warn(args ...interface{}) {
 	if !cl._ControlFlag(WARN) {
 		return
 	}
-	cl.Output(WARN, cl.prefix, 0, args...)
+	cl._Logger().Output(WARN, cl.prefix, 0, args...)
 }
 func (cl *CustomLogger) Error(args ...interface{}) {
 	if !cl._ControlFlag(ERROR) {
 		return
 	}
-	cl.Output(ERROR, cl.prefix, 0, args...)
+	cl._Logger().Output(ERROR, cl.prefix, 0, args...)
 }
 func (cl *CustomLogger) Debugf(format string, args ...interface{}) {
 	if !cl._ControlFlag(DEBUG) {
 		return
 	}
-	cl.Outputf(DEBUG, cl.prefix, 0, format, args...)
+	cl._Logger().Outputf(DEBUG, cl.prefix, 0, format, args...)
 }
 func (cl *CustomLogger) Infof(format string, args ...interface{}) {
 	if !cl._ControlFlag(INFO) {
 		return
 	}
-	cl.Outputf(INFO, cl.prefix, 0, format, args...)
+	cl._Logger().Outputf(INFO, cl.prefix, 0, format, args...)
 }
 func (cl *CustomLogger) Warnf(format string, args ...interface{}) {
 	if !cl._ControlFlag(WARN) {
 		return
 	}
-	cl.Outputf(WARN, cl.prefix, 0, format, args...)
+	cl._Logger().Outputf(WARN, cl.prefix, 0, format, args...)
 }
 func (cl *CustomLogger) Errorf(format string, args ...interface{}) {
 	if !cl._ControlFlag(ERROR) {
 		return
 	}
-	cl.Outputf(ERROR, cl.prefix, 0, format, args...)
+	cl._Logger().Outputf(ERROR, cl.prefix, 0, format, args...)
+}
+func (cl *CustomLogger) Debugw(msg string, fields ...Field) {
+	if !cl._ControlFlag(DEBUG) {
+		return
+	}
+	cl._Logger().OutputFields(DEBUG, cl.prefix, 0, msg, fields...)
+}
+func (cl *CustomLogger) Infow(msg string, fields ...Field) {
+	if !cl._ControlFlag(INFO) {
+		return
+	}
+	cl._Logger().OutputFields(INFO, cl.prefix, 0, msg, fields...)
+}
+func (cl *CustomLogger) Warnw(msg string, fields ...Field) {
+	if !cl._ControlFlag(WARN) {
+		return
+	}
+	cl._Logger().OutputFields(WARN, cl.prefix, 0, msg, fields...)
+}
+func (cl *CustomLogger) Errorw(msg string, fields ...Field) {
+	if !cl._ControlFlag(ERROR) {
+		return
+	}
+	cl._Logger().OutputFields(ERROR, cl.prefix, 0, msg, fields...)
 }
 
 func NewLogByPrefixLevel(prefix string, level Level) Logger {
 	ul := &CustomLogger{
 		prefix: prefix,
-		logger: gLog,
 		level:  level,
 	}
 	return ul
@@ -133,7 +266,6 @@ func NewLogByPrefixLevel(prefix string, level Level) Logger {
 func NewLogByPrefix(prefix string) Logger {
 	ul := &CustomLogger{
 		prefix: prefix,
-		logger: gLog,
 		level:  ERROR,
 	}
 	return ul
@@ -16,6 +16,14 @@ const (
 	MaxLevel = ERROR + 1
 )
 
+// Time formats of LogTimeFormat besides the time layouts, such as
+// time.RFC3339 or time.RFC3339Nano.
+const (
+	TimeFormatGlog      = ""       // yyyymmdd hh:mm:ss.uuuuuu, the default
+	TimeFormatUnixMilli = "unixms" // milliseconds since the Unix epoch
+	TimeFormatUnixNano  = "unixns" // nanoseconds since the Unix epoch
+)
+
 var LevelNames = [MaxLevel]string{
 	DEBUG: "DEBUG",
 	INFO:  "INFO",
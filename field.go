@@ -0,0 +1,163 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"math"
+	"time"
+)
+
+type FieldType uint8
+
+const (
+	UnknownType FieldType = iota
+	BoolType
+	IntType
+	UintType
+	Float64Type
+	Float32Type
+	StringType
+	DurationType
+	TimeType
+	ErrorType
+	AnyType
+	ObjectType
+)
+
+// Field is a typed key/value pair for the w variants (Infow, ...). Scalars
+// are kept in Integer or String, so building a Field does not allocate.
+type Field struct {
+	Key       string
+	Type      FieldType
+	Integer   int64
+	String    string
+	Interface interface{}
+}
+
+func Bool(key string, val bool) Field {
+	var i int64
+	if val {
+		i = 1
+	}
+	return Field{Key: key, Type: BoolType, Integer: i}
+}
+
+func Int(key string, val int) Field     { return Field{Key: key, Type: IntType, Integer: int64(val)} }
+func Int64(key string, val int64) Field { return Field{Key: key, Type: IntType, Integer: val} }
+func Int32(key string, val int32) Field { return Field{Key: key, Type: IntType, Integer: int64(val)} }
+func Uint(key string, val uint) Field   { return Field{Key: key, Type: UintType, Integer: int64(val)} }
+func Uint64(key string, val uint64) Field {
+	return Field{Key: key, Type: UintType, Integer: int64(val)}
+}
+func Uint32(key string, val uint32) Field {
+	return Field{Key: key, Type: UintType, Integer: int64(val)}
+}
+func Float64(key string, val float64) Field {
+	return Field{Key: key, Type: Float64Type, Integer: int64(math.Float64bits(val))}
+}
+func Float32(key string, val float32) Field {
+	return Field{Key: key, Type: Float32Type, Integer: int64(math.Float32bits(val))}
+}
+func Str(key string, val string) Field { return Field{Key: key, Type: StringType, String: val} }
+func Dur(key string, val time.Duration) Field {
+	return Field{Key: key, Type: DurationType, Integer: int64(val)}
+}
+
+// Time keeps the location pointer, so the field renders in t's time zone.
+// The times UnixNano cannot hold, before 1678 or after 2262 as the zero
+// time, are kept whole in Interface.
+func Time(key string, val time.Time) Field {
+	if n := val.UnixNano(); time.Unix(0, n).Equal(val) {
+		return Field{Key: key, Type: TimeType, Integer: n, Interface: val.Location()}
+	}
+	return Field{Key: key, Type: TimeType, Interface: val}
+}
+
+// Err is NamedErr with the key "error".
+func Err(err error) Field { return NamedErr("error", err) }
+
+func NamedErr(key string, err error) Field {
+	if err == nil {
+		return Field{Key: key, Type: AnyType}
+	}
+	return Field{Key: key, Type: ErrorType, Interface: err}
+}
+
+// Any renders val like an Info argument, prefer the typed constructors.
+func Any(key string, val interface{}) Field { return Field{Key: key, Type: AnyType, Interface: val} }
+
+func DebugFieldsAppend(buf *Buffer, fields ...Field) { appendFields2Buffer(buf, fields) }
+
+// appendFields2Buffer writes " key=value" for every field. String values
+// are quoted when they are empty or contain spaces, '=' or '"'.
+func appendFields2Buffer(buf *Buffer, fields []Field) {
+	for i := range fields {
+		f := &fields[i]
+		_ = buf.WriteByte(' ')
+		_, _ = buf.WriteString(f.Key)
+		_ = buf.WriteByte('=')
+		appendField2Buffer(buf, f)
+	}
+}
+
+func appendField2Buffer(buf *Buffer, f *Field) {
+	switch f.Type {
+	case BoolType:
+		buf.AppendBool(f.Integer == 1)
+	case IntType:
+		buf.AppendInt(f.Integer)
+	case UintType:
+		buf.AppendUint(uint64(f.Integer))
+	case Float64Type:
+		buf.AppendFloat(math.Float64frombits(uint64(f.Integer)), 64)
+	case Float32Type:
+		buf.AppendFloat(float64(math.Float32frombits(uint32(f.Integer))), 32)
+	case StringType:
+		appendString2Buffer(buf, f.String)
+	case DurationType:
+		appendDuration(buf, time.Duration(f.Integer))
+	case TimeType:
+		t, ok := f.Interface.(time.Time)
+		if !ok {
+			t = time.Unix(0, f.Integer)
+			if loc, ok := f.Interface.(*time.Location); ok {
+				t = t.In(loc)
+			}
+		}
+		buf.AppendTime(t, time.RFC3339Nano)
+	case ErrorType:
+		appendString2Buffer(buf, f.Interface.(error).Error())
+	case ObjectType:
+		appendMarshaler(buf, f.Interface.(LogMarshaler))
+	case AnyType:
+		if f.Interface == nil {
+			_, _ = buf.WriteString("<nil>")
+			return
+		}
+		appendArg2Buffer(buf, f.Interface)
+	default:
+		_, _ = buf.WriteString("<unknown>")
+	}
+}
+
+func appendString2Buffer(buf *Buffer, s string) {
+	if needsQuote(s) {
+		buf.AppendQuote(s)
+		return
+	}
+	_, _ = buf.WriteString(s)
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
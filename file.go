@@ -6,11 +6,14 @@ package jlog
 
 import (
 	"bytes"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/tiger-game/jlog/utils"
@@ -19,11 +22,24 @@ import (
 const DefaultLoggerLevel = INFO
 
 type logFile struct {
-	streams [MaxLevel]FileStream
-	level   Level
-	path    string
-	logName string
-	debug   bool
+	streams    [MaxLevel]FileStream
+	console    [MaxLevel]consoleStream
+	consoleOut [MaxLevel]io.Writer // LogConsoleWriter targets
+	level      int32               // Level, atomic for the live reload
+	path       string
+	logName    string
+	debug      bool
+	color      colorMode
+	loc        *time.Location // LogTimeZone, nil for the local one
+	clock      Clock
+	maxSize    int
+	maxAge     time.Duration // LogRetention
+	maxFiles   int
+	sinks      [MaxLevel][]consoleStream
+	sinkOut    [MaxLevel][]io.Writer // LogSink targets
+	closers    []io.Closer           // the sink files opened for a Config
+	iov        [][]byte              // scratch for WriteBatch
+	colored    []byte                // scratch for the colored console lines
 }
 
 func (l *logFile) _InitLogPath(path string) {
@@ -32,32 +48,35 @@ func (l *logFile) _InitLogPath(path string) {
 		_StdLog().Errorf("LoggerFile CreateDir Error: %v", err)
 	}
 	l.logName = WithoutExt(filepath.Base(os.Args[0]))
-	l._CheckFileIndex()
 }
 
-func (l *logFile) _InitStdLog() {
-	var rawFile *os.File
-	for lv := range LevelExtNames {
-		if !l._Check(Level(lv)) {
-			continue
-		}
-		rawFile = os.Stdout
-		if lv == int(ERROR) {
-			rawFile = os.Stderr
+func (l *logFile) _InitSinks() {
+	for lv, outs := range l.sinkOut {
+		for _, out := range outs {
+			var s consoleStream
+			s._Init(out, colorOff)
+			l.sinks[lv] = append(l.sinks[lv], s)
 		}
-		l.streams[lv]._Init(rawFile)
 	}
 }
 
 func (l *logFile) SetDefaultLevel() {
 	// set default level for gLog
-	if l.level < DefaultLoggerLevel {
-		l.level = DefaultLoggerLevel
+	if l._Level() < DefaultLoggerLevel {
+		l._SetLevel(DefaultLoggerLevel)
+	}
+}
+
+// _Now returns the time of the clock in the zone of the logger.
+func (l *logFile) _Now() time.Time {
+	if l.loc != nil {
+		return l.clock.Now().In(l.loc)
 	}
+	return l.clock.Now()
 }
 
 func (l *logFile) _CheckFileIndex() {
-	timeStr := time.Now().Format(timeFormat)
+	timeStr := l._Now().Format(timeFormat)
 
 	_ = filepath.WalkDir(l.path, func(path string, d fs.DirEntry, err error) error {
 		if d == nil || d.IsDir() {
@@ -104,9 +123,12 @@ func parseIdxAndLv(timeStr, fname string) (int, Level) {
 }
 
 func (l *logFile) _Check(lv Level) bool {
-	return (lv == DEBUG && l.debug) || (lv != DEBUG && lv <= l.level)
+	return (lv == DEBUG && l.debug) || (lv != DEBUG && lv <= l._Level())
 }
 
+func (l *logFile) _Level() Level      { return Level(atomic.LoadInt32(&l.level)) }
+func (l *logFile) _SetLevel(lv Level) { atomic.StoreInt32(&l.level, int32(lv)) }
+
 func (l *logFile) _NewCreateFile(lv Level) {
 	var (
 		err     error
@@ -120,11 +142,82 @@ func (l *logFile) _NewCreateFile(lv Level) {
 	}
 	l.streams[lv]._Init(rawFile)
 	l.streams[lv].SymLink(l._RedirectFile(lv))
+	l._Prune(lv)
+}
+
+// _Prune removes the rotated files of lv past the retention, never the
+// current one.
+func (l *logFile) _Prune(lv Level) {
+	if l.maxAge <= 0 && l.maxFiles <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(l.path)
+	if err != nil {
+		_StdLog().Errorf("logFile Prune Error: %v", err)
+		return
+	}
+
+	type rotated struct {
+		name string
+		hour time.Time
+		idx  int
+	}
+	var (
+		files  []rotated
+		prefix = l.logName + "."
+		suffix = "." + LevelExtNames[lv] + ".log"
+		loc    = l.loc
+	)
+	if loc == nil {
+		loc = time.Local
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || len(name) <= len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		// format: logName.YYYYMMDDHH.idx.ext.log
+		mid := name[len(prefix) : len(name)-len(suffix)]
+		i := strings.IndexByte(mid, '.')
+		if i != len(timeFormat) {
+			continue
+		}
+		hour, err := time.ParseInLocation(timeFormat, mid[:i], loc)
+		if err != nil {
+			continue
+		}
+		idx, err := utils.Str2Int(mid[i+1:])
+		if err != nil {
+			continue
+		}
+		files = append(files, rotated{name: name, hour: hour, idx: idx})
+	}
+
+	// newest first
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].hour.Equal(files[j].hour) {
+			return files[i].hour.After(files[j].hour)
+		}
+		return files[i].idx > files[j].idx
+	})
+	current := filepath.Base(l.streams[lv].rawFile.Name())
+	expire := l._Now().Add(-l.maxAge)
+	for i, f := range files {
+		if f.name == current {
+			continue
+		}
+		// a file takes the records of its hour.
+		if (l.maxFiles > 0 && i >= l.maxFiles) || (l.maxAge > 0 && f.hour.Add(time.Hour).Before(expire)) {
+			if err = os.Remove(filepath.Join(l.path, f.name)); err != nil {
+				_StdLog().Errorf("logFile Prune Error: %v", err)
+			}
+		}
+	}
 }
 
 func (l *logFile) _DirFileName(lv Level) string {
 	var (
-		timeStr = time.Now().Format(timeFormat)
+		timeStr = l._Now().Format(timeFormat)
 		ext     = LevelExtNames[lv]
 	)
 	buffer := bytes.NewBuffer(make([]byte, 0, len(l.logName)+len(timeStr)+len(ext)+6))
@@ -150,25 +243,97 @@ func (l *logFile) _RedirectFile(lv Level) string {
 	return filepath.Join(l.path, buffer.String())
 }
 
+// Close flushes the console and the sinks and closes the files.
 func (l *logFile) Close() {
-	for _, f := range l.streams {
-		f.Close()
+	for lv := range l.streams {
+		_ = l.console[lv].Flush()
+		for i := range l.sinks[lv] {
+			_ = l.sinks[lv][i].Flush()
+		}
+		l.streams[lv].Close()
+	}
+	l._CloseSinkFiles()
+}
+
+// _SinkFiles returns the sink files of the Config by their path.
+func (l *logFile) _SinkFiles() map[string]*os.File {
+	files := make(map[string]*os.File, len(l.closers))
+	for _, c := range l.closers {
+		if f, ok := c.(*os.File); ok {
+			files[f.Name()] = f
+		}
+	}
+	return files
+}
+
+func (l *logFile) _CloseSinkFiles() {
+	for _, c := range l.closers {
+		if err := c.Close(); err != nil {
+			_StdLog().Errorf("logFile Close Error: %v", err)
+		}
 	}
+	l.closers = nil
 }
 
-func (l *logFile) Write(level Level, data []byte, notCreateFile bool) (err error) {
-	for lv := DEBUG; lv <= level; lv++ {
-		if notCreateFile && lv != level {
+// _ResetSinks replaces the sinks, the old ones are flushed and the files of
+// the old Config closed, but those closers keeps.
+func (l *logFile) _ResetSinks(outs [MaxLevel][]io.Writer, closers []io.Closer) {
+	for lv := range l.sinks {
+		for i := range l.sinks[lv] {
+			if err := l.sinks[lv][i].Flush(); err != nil {
+				_StdLog().Errorf("logFile Flush Error: %v", err)
+			}
+		}
+		l.sinks[lv] = nil
+	}
+	for _, c := range l.closers {
+		if hasCloser(closers, c) {
 			continue
 		}
+		if err := c.Close(); err != nil {
+			_StdLog().Errorf("logFile Close Error: %v", err)
+		}
+	}
+	l.sinkOut, l.closers = outs, closers
+	l._InitSinks()
+}
+
+// WriteBatch writes the rendered lines, a record goes to the console stream
+// of its level and to the files and sinks of its level and below, every file
+// receives its share of the batch in a single Writev.
+func (l *logFile) WriteBatch(lines []*Buffer, notCreateFile bool) (err error) {
+	for lv := DEBUG; lv < MaxLevel; lv++ {
+		for i := range l.sinks[lv] {
+			l._WriteSink(&l.sinks[lv][i], lv, lines)
+		}
 		if !l._Check(lv) {
 			continue
 		}
+		if l.console[lv].IsWriter() {
+			l._WriteConsole(lv, lines)
+		}
+		if notCreateFile {
+			continue
+		}
 
-		if !notCreateFile && (l.streams[lv].OverflowMaxSize() || l.streams[lv].RotateByTime()) {
+		l.iov = l.iov[:0]
+		for _, buf := range lines {
+			if buf.lv >= lv {
+				l.iov = append(l.iov, buf.Bytes())
+			}
+		}
+		if len(l.iov) == 0 {
+			continue
+		}
+
+		switch s := &l.streams[lv]; {
+		case s.RotateByTime(l._Now()):
+			l._NewCreateFile(lv)
+		case s.OverflowMaxSize(l.maxSize):
+			s.idx++
 			l._NewCreateFile(lv)
 		}
-		if err = l.streams[lv].Write(data); err != nil {
+		if err = l.streams[lv].Writev(l.iov); err != nil {
 			_StdLog().Errorf("logFile Write Error: %v", err)
 			return err
 		}
@@ -178,6 +343,14 @@ func (l *logFile) Write(level Level, data []byte, notCreateFile bool) (err error
 
 func (l *logFile) Flush() {
 	for lv := DEBUG; lv < MaxLevel; lv++ {
+		if err := l.console[lv].Flush(); err != nil {
+			_StdLog().Errorf("logFile Flush Error: %v", err)
+		}
+		for i := range l.sinks[lv] {
+			if err := l.sinks[lv][i].Flush(); err != nil {
+				_StdLog().Errorf("logFile Flush Error: %v", err)
+			}
+		}
 		if !l.streams[lv].IsWriter() {
 			continue
 		}
@@ -187,3 +360,26 @@ func (l *logFile) Flush() {
 		}
 	}
 }
+
+// Sync flushes every open stream and, when fsync is set, commits the file
+// contents to stable storage.
+func (l *logFile) Sync(fsync bool) (err error) {
+	for lv := DEBUG; lv < MaxLevel; lv++ {
+		if e := l.console[lv].Flush(); e != nil && err == nil {
+			err = e
+		}
+		for i := range l.sinks[lv] {
+			if e := l.sinks[lv][i].Flush(); e != nil && err == nil {
+				err = e
+			}
+		}
+		if !l.streams[lv].IsWriter() {
+			continue
+		}
+
+		if e := l.streams[lv].Sync(fsync); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
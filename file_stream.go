@@ -14,19 +14,28 @@ import (
 
 const MaxSize = 1e9 // 1GB
 
+// maxGather bounds the Writev scratch buffer kept between batches.
+const maxGather = 1 << 20
+
 type FileStream struct {
 	rawFile    *os.File
 	writer     *bufio.Writer
 	writeSize  int
 	createTime int64
 	idx        int
+	gather     []byte // scratch for Writev
 }
 
-func (f *FileStream) IsWriter() bool        { return f.writer != nil && f.rawFile != nil }
-func (f *FileStream) OverflowMaxSize() bool { return f.writeSize >= MaxSize }
-func (f *FileStream) RotateByTime() bool {
-	nowNano := time.Now().UnixNano()
-	if f.createTime/int64(time.Hour) >= nowNano/int64(time.Hour) {
+func (f *FileStream) IsWriter() bool               { return f.writer != nil && f.rawFile != nil }
+func (f *FileStream) OverflowMaxSize(max int) bool { return f.writeSize >= max }
+
+// RotateByTime reports whether now, in the zone of the file names, is past
+// the hour the file was created in.
+func (f *FileStream) RotateByTime(now time.Time) bool {
+	_, offset := now.Zone()
+	nowNano := now.UnixNano()
+	zone := int64(offset) * int64(time.Second)
+	if (f.createTime+zone)/int64(time.Hour) >= (nowNano+zone)/int64(time.Hour) {
 		return false
 	}
 	if f.createTime != 0 {
@@ -50,6 +59,40 @@ func (f *FileStream) Write(p []byte) error {
 	return err
 }
 
+// Writev writes bufs in order. A batch fitting the buffer is only copied,
+// a larger one is gathered and written with a single write call.
+func (f *FileStream) Writev(bufs [][]byte) error {
+	if !f.IsWriter() {
+		return nil
+	}
+
+	var size int
+	for _, b := range bufs {
+		size += len(b)
+	}
+	if size <= f.writer.Available() {
+		for _, b := range bufs {
+			_, _ = f.writer.Write(b)
+		}
+		f.writeSize += size
+		return nil
+	}
+
+	if err := f.writer.Flush(); err != nil {
+		return err
+	}
+	f.gather = f.gather[:0]
+	for _, b := range bufs {
+		f.gather = append(f.gather, b...)
+	}
+	n, err := f.rawFile.Write(f.gather)
+	f.writeSize += n
+	if cap(f.gather) > maxGather {
+		f.gather = nil
+	}
+	return err
+}
+
 func (f *FileStream) Flush() error {
 	if !f.IsWriter() {
 		return fmt.Errorf("FileStream Flush Error: f.rawFile == nil(%v), f.writer == nil(%v)", f.rawFile == nil, f.writer == nil)
@@ -57,6 +100,16 @@ func (f *FileStream) Flush() error {
 	return f.writer.Flush()
 }
 
+func (f *FileStream) Sync(fsync bool) error {
+	if err := f.Flush(); err != nil {
+		return err
+	}
+	if !fsync {
+		return nil
+	}
+	return f.rawFile.Sync()
+}
+
 func (f *FileStream) SymLink(dst string) {
 	var err error
 	if err = os.RemoveAll(dst); err != nil {
@@ -84,5 +137,4 @@ func (f *FileStream) Close() {
 	f.writer = nil
 	f.rawFile = nil
 	f.writeSize = 0
-	f.idx++
 }
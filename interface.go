@@ -14,3 +14,57 @@ type Logger interface {
 	Warnf(format string, args ...interface{})
 	Errorf(format string, args ...interface{})
 }
+
+// FieldLogger is implemented by the Loggers taking typed fields, the w
+// variants. It is kept out of Logger so the implementations outside this
+// package need not grow with it, check for it with a type assertion.
+type FieldLogger interface {
+	Debugw(msg string, fields ...Field)
+	Infow(msg string, fields ...Field)
+	Warnw(msg string, fields ...Field)
+	Errorw(msg string, fields ...Field)
+}
+
+// Syncer is implemented by the Loggers queueing their records, Sync blocks
+// until the queued ones are written.
+type Syncer interface {
+	Sync() error
+}
+
+// LevelEnabler is implemented by the Loggers filtering the levels, Enabled
+// reports whether a record of lv would be written.
+type LevelEnabler interface {
+	Enabled(lv Level) bool
+}
+
+// _Output forwards a record to lg. The prefix, level filter and caller depth
+// are kept when lg is one of this package's loggers, any other implementation
+// only receives the level. A non empty prefix overrides the one of lg, depth
+// counts the frames above the caller of _Output.
+func _Output(lg Logger, lv Level, prefix string, depth int, args ...interface{}) {
+	switch l := lg.(type) {
+	case *logger:
+		l.Output(lv, prefix, depth+1, args...)
+	case *CustomLogger:
+		if prefix == "" {
+			prefix = l.prefix
+		}
+		if l._ControlFlag(lv) {
+			l._Logger().Output(lv, prefix, depth+1, args...)
+		}
+	default:
+		if prefix != "" {
+			args = append([]interface{}{"[" + prefix + "]"}, args...)
+		}
+		switch lv {
+		case DEBUG:
+			lg.Debug(args...)
+		case INFO:
+			lg.Info(args...)
+		case WARN:
+			lg.Warn(args...)
+		default:
+			lg.Error(args...)
+		}
+	}
+}
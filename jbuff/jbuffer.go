@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"time"
 	"unicode/utf8"
 	"unsafe"
 
@@ -372,6 +373,15 @@ func (j *JBuffer) AppendUint(i uint64) { j.buf = strconv.AppendUint(j.buf, i, 10
 // AppendBool appends a bool to the underlying buffer.
 func (j *JBuffer) AppendBool(v bool) { j.buf = strconv.AppendBool(j.buf, v) }
 
+// AppendQuote appends s as a double-quoted Go string literal.
+func (j *JBuffer) AppendQuote(s string) { j.buf = strconv.AppendQuote(j.buf, s) }
+
+// AppendJSONString appends s quoted as a JSON string, see AppendJSONString.
+func (j *JBuffer) AppendJSONString(s string) { j.buf = AppendJSONString(j.buf, s) }
+
+// AppendTime appends t formatted by layout, see time.Time.AppendFormat.
+func (j *JBuffer) AppendTime(t time.Time, layout string) { j.buf = t.AppendFormat(j.buf, layout) }
+
 // AppendFloat appends a float to the underlying buffer. It doesn't quote NaN
 // or +/- Inf.
 func (j *JBuffer) AppendFloat(f float64, bitSize int) {
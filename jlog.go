@@ -5,25 +5,60 @@
 package jlog
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
-	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
+
+	"github.com/tiger-game/jlog/jring"
+)
+
+// queueSize is the number of records buffered between the callers and the
+// writer goroutine, batchSize the number written out per batch.
+const (
+	queueSize = 1 << 10
+	batchSize = 1 << 8
 )
 
 type logger struct {
 	file       logFile
-	logCh      chan logData
+	queue      *jring.MPSC // *Buffer, *logData, a Sync barrier (chan error) or a _Reconfigure func()
+	sleeping   int32       // set while the writer waits for wake
+	wake       chan struct{}
+	parked     int32      // producers waiting on notFull for room in queue
+	fullMu     sync.Mutex // guards notFull
+	notFull    *sync.Cond
 	pool       Pool
-	std        bool
+	std        bool                // tee the records to the console
+	async      bool                // format records on the writer goroutine
+	timeLayout string              // LogTimeFormat, TimeFormatGlog takes the fast path
+	caller     bool                // LogCaller, always on in dev builds
+	prefixLv   unsafe.Pointer      // *map[string]Level of LogPrefixLevels
+	sampling   unsafe.Pointer      // *sampler of LogSampling
+	redactKeys map[string]struct{} // LogRedactFields
+	scrubbers  []scrubber          // LogScrub
+	multiline  string              // LogMultiline
+	sync       bool                // write records on the calling goroutine under mu
+	lines      []*Buffer
 	closeWrite chan error
 	waitClose  chan struct{}
 	closed     chan struct{}
+	closeOnce  sync.Once
+	mu         sync.Mutex // held while writing in sync mode
+	isClosed   bool
+	closing    int32          // set by Close, _Push refuses the records from then on
+	pushing    int32          // the _Push calls in flight, the writer drains them on close
+	next       unsafe.Pointer // *logger taking the records once closed, set by _Swap
 }
 
 type logData struct {
-	lv     Level
+	lv  Level
+	out *Buffer // the rendered line, nil when format is left to the writer
+	// the record to format on the writer goroutine with LogAsyncFormat.
 	file   string
 	line   int
 	debug  bool
@@ -32,40 +67,213 @@ type logData struct {
 
 func NewLogger(opts ...Option) *logger {
 	l := &logger{
+		queue:      jring.New(queueSize),
+		wake:       make(chan struct{}, 1),
 		pool:       NewPool(),
-		logCh:      make(chan logData, 1<<6),
 		closeWrite: make(chan error, 1),
 		waitClose:  make(chan struct{}),
 		closed:     make(chan struct{}),
 	}
+	l.notFull = sync.NewCond(&l.fullMu)
 
+	l.file.clock = systemClock{}
+	l.file.maxSize = MaxSize
 	l.file.SetDefaultLevel()
+	l.multiline = MultilineRaw
 	for _, opt := range opts {
 		opt(l)
 	}
 
-	if l.IsNotCreateFile() {
-		l.file._InitStdLog()
+	if l.file.path != "" {
+		// after the options, the clock and the zone name the files.
+		l.file._CheckFileIndex()
+	}
+	if l.std {
+		l.file._InitConsole()
+	}
+	l.file._InitSinks()
+
+	if l.sync {
+		return l
 	}
 
 	go func() {
-		if err := l._GoLogger(); err != nil {
-			l._CloseWithErr(err)
-		}
+		err := l._GoLogger()
 		close(l.closed)
-		if !l.std {
-			l.file.Close()
-		}
+		l._WakeProducers()
+		l._CloseWithErr(err)
+		l.file.Close()
 		close(l.waitClose)
 	}()
 	return l
 }
 
+// Enabled reports whether a record of level lv would be written, so callers
+// can skip building expensive arguments.
+func (l *logger) Enabled(lv Level) bool { return l != nil && l.file._Check(lv) }
+
+func (l *logger) _SetPrefixLevels(levels map[string]Level) {
+	var p unsafe.Pointer
+	if len(levels) > 0 {
+		m := make(map[string]Level, len(levels))
+		for prefix, lv := range levels {
+			m[prefix] = lv
+		}
+		p = unsafe.Pointer(&m)
+	}
+	atomic.StorePointer(&l.prefixLv, p)
+}
+
+// _Enabled is Enabled for a record of prefix, under its LogPrefixLevels.
+func (l *logger) _Enabled(lv Level, prefix string) bool {
+	if l == nil || !l.file._Check(lv) {
+		return false
+	}
+	if prefix == "" {
+		return true
+	}
+	if levels := (*map[string]Level)(atomic.LoadPointer(&l.prefixLv)); levels != nil {
+		if level, ok := (*levels)[prefix]; ok {
+			return lv <= level
+		}
+	}
+	return true
+}
+
+// IsNotCreateFile reports whether the logger writes to the console only.
 func (l *logger) IsNotCreateFile() bool { return l.std && l.file.path == "" }
 
-func (l *logger) Close() {
-	close(l.closeWrite)
-	<-l.waitClose
+// Close stops accepting records, writes out everything already queued and
+// closes the files. It is safe to call more than once.
+func (l *logger) Close() { _ = l.CloseContext(context.Background()) }
+
+// CloseContext is like Close but gives up waiting for the queued records to
+// be written once ctx is done. The logger is closed for new records either way.
+func (l *logger) CloseContext(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.closeOnce.Do(func() {
+		l.mu.Lock()
+		l.isClosed = true
+		if l.sync {
+			l.file.Flush()
+			l.file.Close()
+			l.mu.Unlock()
+			close(l.closed)
+			close(l.waitClose)
+			return
+		}
+		l.mu.Unlock()
+		atomic.StoreInt32(&l.closing, 1)
+		close(l.closeWrite)
+	})
+
+	select {
+	case <-l.waitClose:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sync blocks until every record queued before the call has been written
+// out and the underlying files are flushed and fsynced.
+func (l *logger) Sync() error {
+	if l == nil {
+		return nil
+	}
+	if l.sync {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.isClosed {
+			return nil
+		}
+		return l.file.Sync(!l.IsNotCreateFile())
+	}
+	done := make(chan error, 1)
+	if !l._Push(done) {
+		return nil
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-l.waitClose:
+		return nil
+	}
+}
+
+// _Push queues v for the writer goroutine, parking while the queue is full.
+// It reports false once the logger is closed.
+func (l *logger) _Push(v interface{}) bool {
+	// announce the push before the look at closing, Close stores closing
+	// before the writer looks at pushing, one of them sees the other.
+	atomic.AddInt32(&l.pushing, 1)
+	defer atomic.AddInt32(&l.pushing, -1)
+	if atomic.LoadInt32(&l.closing) == 1 {
+		return false
+	}
+	for !l.queue.Push(v) {
+		l._Wake()
+		l.fullMu.Lock()
+		atomic.AddInt32(&l.parked, 1)
+		// retry under fullMu, the writer broadcasts under it after a pop.
+		pushed := l.queue.Push(v)
+		if !pushed && !l._WriterDone() {
+			l.notFull.Wait()
+		}
+		atomic.AddInt32(&l.parked, -1)
+		l.fullMu.Unlock()
+		if pushed {
+			break
+		}
+		if l._WriterDone() {
+			return false
+		}
+	}
+	l._Wake()
+	return true
+}
+
+// _WakeProducers wakes the producers parked on the full queue.
+func (l *logger) _WakeProducers() {
+	if atomic.LoadInt32(&l.parked) > 0 {
+		l.fullMu.Lock()
+		l.notFull.Broadcast()
+		l.fullMu.Unlock()
+	}
+}
+
+// _WriterDone reports whether the writer goroutine has returned.
+func (l *logger) _WriterDone() bool {
+	select {
+	case <-l.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// _Wake wakes the writer goroutine if it waits for records.
+func (l *logger) _Wake() {
+	if atomic.LoadInt32(&l.sleeping) == 1 {
+		select {
+		case l.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// _Discard hands a record that can no longer be queued to the logger that
+// replaced l, see _Swap, or writes it straight to stderr.
+func (l *logger) _Discard(data logData) {
+	if next := _Load(&l.next); next != nil {
+		next._Enqueue(data)
+		return
+	}
+	buf := l._Format(data)
+	_, _ = fmt.Fprintf(os.Stderr, "logger discard: %s", buf.String())
+	buf.Free()
 }
 
 func (l *logger) _CloseWithErr(err error) {
@@ -77,7 +285,8 @@ func (l *logger) _CloseWithErr(err error) {
 
 func (l *logger) _GoLogger() (err error) {
 	var (
-		data   logData
+		batch  = make([]interface{}, 0, batchSize)
+		lines  = make([]*Buffer, 0, batchSize)
 		ticker *time.Ticker
 	)
 
@@ -85,92 +294,152 @@ func (l *logger) _GoLogger() (err error) {
 	defer ticker.Stop()
 
 	for {
-		if l.closeWrite == nil && len(l.logCh) == 0 {
-			l.logCh = nil
-			l.file.Flush()
-			return
+		if batch = l.queue.PopBatch(batch[:0]); len(batch) > 0 {
+			l._WakeProducers()
+			if err = l._Handle(batch, lines); err != nil {
+				return
+			}
+			select {
+			case <-ticker.C:
+				l.file.Flush()
+			default:
+			}
+			continue
 		}
 
+		// announce the wait before the last look, so a producer pushing
+		// after it is sure to see sleeping and wake us.
+		atomic.StoreInt32(&l.sleeping, 1)
+		if l.queue.Len() > 0 {
+			atomic.StoreInt32(&l.sleeping, 0)
+			continue
+		}
 		select {
-		case data = <-l.logCh:
-			buf := l.formatHeaderWithBodyFunction(data.lv, data.file, data.line, data.format, data.debug)
-			if err = l.file.Write(data.lv, buf.Bytes(), l.IsNotCreateFile()); err != nil {
-				buf.Free()
-				return
-			}
-			buf.Free()
+		case <-l.wake:
 		case <-ticker.C:
 			l.file.Flush()
 		case err = <-l.closeWrite:
-			l.closeWrite = nil
+			// drain the queue and the pushes still in flight, the pushes
+			// after them see closing.
+			for {
+				if batch = l.queue.PopBatch(batch[:0]); len(batch) > 0 {
+					l._WakeProducers()
+					if err = l._Handle(batch, lines); err != nil {
+						return
+					}
+					continue
+				}
+				if atomic.LoadInt32(&l.pushing) == 0 && l.queue.Len() == 0 {
+					break
+				}
+				runtime.Gosched()
+			}
+			l.file.Flush()
+			return
 		}
+		atomic.StoreInt32(&l.sleeping, 0)
 	}
 }
 
-func DebugBufferAppend(buf *Buffer, arg interface{}) { appendArg2Buffer(buf, arg) }
-
-func appendArg2Buffer(buf *Buffer, arg interface{}) {
-	switch val := arg.(type) {
-	case bool:
-		buf.AppendBool(val)
-	case int:
-		buf.AppendInt(int64(val))
-	case int8:
-		buf.AppendInt(int64(val))
-	case int16:
-		buf.AppendInt(int64(val))
-	case int32:
-		buf.AppendInt(int64(val))
-	case int64:
-		buf.AppendInt(val)
-	case uint:
-		buf.AppendUint(uint64(val))
-	case byte:
-		_ = buf.WriteByte(val)
-	case uint16:
-		buf.AppendUint(uint64(val))
-	case uint32:
-		buf.AppendUint(uint64(val))
-	case uint64:
-		buf.AppendUint(val)
-	case float32:
-		buf.AppendFloat(float64(val), 32)
-	case float64:
-		buf.AppendFloat(val, 64)
-	case []byte:
-		_, _ = buf.Write(val)
-	case string:
-		_, _ = buf.WriteString(val)
-	case error:
-		_, _ = buf.WriteString(val.Error())
-	default:
-		appendValue2Buffer(buf, arg)
-	}
-}
-
-func appendValue2Buffer(buf *Buffer, value interface{}) {
-	switch val := reflect.ValueOf(value); val.Kind() {
-	case reflect.Bool:
-		buf.AppendBool(val.Bool())
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		buf.AppendInt(val.Int())
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
-		buf.AppendUint(val.Uint())
-	case reflect.Float32:
-		buf.AppendFloat(val.Float(), 32)
-	case reflect.Float64:
-		buf.AppendFloat(val.Float(), 64)
-	case reflect.String:
-		_, _ = buf.WriteString(val.String())
-	default:
-		enc := json.NewEncoder(buf)
-		if err := enc.Encode(value); err != nil {
-			_StdLog().Errorf("appendArg2Buffer default json marshal Error: %v", err)
-			return
+// _Handle writes a batch popped from the queue, consecutive records are
+// written together, a Sync barrier first writes what precedes it.
+func (l *logger) _Handle(batch []interface{}, lines []*Buffer) (err error) {
+	lines = lines[:0]
+	for i, v := range batch {
+		batch[i] = nil
+		switch v := v.(type) {
+		case *Buffer:
+			lines = append(lines, v)
+		case *logData:
+			lines = append(lines, l._Format(*v))
+		case chan error:
+			if err = l._WriteLines(lines); err != nil {
+				return
+			}
+			lines = lines[:0]
+			v <- l.file.Sync(!l.IsNotCreateFile())
+		case func():
+			if err = l._WriteLines(lines); err != nil {
+				return
+			}
+			lines = lines[:0]
+			v()
 		}
 	}
+	return l._WriteLines(lines)
+}
+
+func (l *logger) _WriteLines(lines []*Buffer) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	err := l.file.WriteBatch(lines, l.IsNotCreateFile())
+	for i, buf := range lines {
+		buf.Free()
+		lines[i] = nil
+	}
+	return err
 }
 
+// _Format returns the rendered line of data.
+func (l *logger) _Format(data logData) *Buffer {
+	if data.out != nil {
+		return data.out
+	}
+	return l.formatHeaderWithBodyFunction(data.lv, data.file, data.line, data.format, data.debug)
+}
+
+// _Record renders the record on the calling goroutine, so later changes to
+// the arguments can not show up in the log, unless LogAsyncFormat is set.
+func (l *logger) _Record(lv Level, file string, line int, debug bool, bodyFn func(buf *Buffer)) logData {
+	if l.async {
+		return logData{lv: lv, file: file, line: line, debug: debug, format: bodyFn}
+	}
+	return logData{lv: lv, out: l.formatHeaderWithBodyFunction(lv, file, line, bodyFn, debug)}
+}
+
+// _Enqueue hands data to the writer goroutine, or to stderr once closed.
+func (l *logger) _Enqueue(data logData) {
+	if l.sync {
+		l._WriteSync(data)
+		return
+	}
+	var v interface{} = data.out
+	if data.out == nil {
+		// only LogAsyncFormat records pay for the copy.
+		d := new(logData)
+		*d = data
+		v = d
+	}
+	if !l._Push(v) {
+		l._Discard(data)
+	}
+}
+
+// _WriteSync writes data and flushes it before returning, like the writer
+// goroutine would, for the loggers created with LogSync.
+func (l *logger) _WriteSync(data logData) {
+	buf := l._Format(data)
+	l.mu.Lock()
+	if l.isClosed {
+		l.mu.Unlock()
+		l._Discard(logData{lv: data.lv, out: buf})
+		return
+	}
+	// write errors are reported by the logFile.
+	l.lines = append(l.lines[:0], buf)
+	_ = l._WriteLines(l.lines)
+	l.file.Flush()
+	l.mu.Unlock()
+}
+
+func DebugBufferAppend(buf *Buffer, arg interface{}) { appendArg2Buffer(buf, arg) }
+
+// AppendFieldString writes s like the value of a Str field, quoted when it
+// would be ambiguous in a key=value list, for adapters rendering their own
+// fields.
+func AppendFieldString(buf *Buffer, s string) { appendString2Buffer(buf, s) }
+
 func (l *logger) Debug(args ...interface{}) { l.Output(DEBUG, "", 0, args...) }
 func (l *logger) Info(args ...interface{})  { l.Output(INFO, "", 0, args...) }
 func (l *logger) Warn(args ...interface{})  { l.Output(WARN, "", 0, args...) }
@@ -191,75 +460,122 @@ func (l *logger) Errorf(format string, args ...interface{}) {
 	l.Outputf(ERROR, "", 0, format, args...)
 }
 
+func (l *logger) Debugw(msg string, fields ...Field) {
+	l.OutputFields(DEBUG, "", 0, msg, fields...)
+}
+
+func (l *logger) Infow(msg string, fields ...Field) {
+	l.OutputFields(INFO, "", 0, msg, fields...)
+}
+
+func (l *logger) Warnw(msg string, fields ...Field) {
+	l.OutputFields(WARN, "", 0, msg, fields...)
+}
+
+func (l *logger) Errorw(msg string, fields ...Field) {
+	l.OutputFields(ERROR, "", 0, msg, fields...)
+}
+
 func (l *logger) Output(lv Level, prefix string, depth int, args ...interface{}) {
-	if l == nil || !l.file._Check(lv) || len(args) == 0 {
+	if !l._Allow(lv, prefix) || len(args) == 0 {
 		return
 	}
 	file, line, debug := l.formatMsg(depth)
 	formatFunc := func(buf *Buffer) {
-		if prefix != "" {
-			_ = buf.WriteByte('[')
-			_, _ = buf.WriteString(prefix)
-			_ = buf.WriteByte(']')
-		}
+		appendPrefix2Buffer(buf, prefix)
 		for _, arg := range args {
 			appendArg2Buffer(buf, arg)
 			_ = buf.WriteByte(' ')
 		}
 	}
-
-	select {
-	case l.logCh <- logData{
-		lv:     lv,
-		file:   file,
-		line:   line,
-		format: formatFunc,
-		debug:  debug,
-	}:
-	case <-l.closed:
-		if l.std {
-			return
-		}
-		buf := l.formatHeaderWithBodyFunction(lv, file, line, formatFunc, debug)
-		if l.std {
-			_, _ = fmt.Fprintf(os.Stderr, "logger discard: %s", buf.String())
-		} else {
-			_StdLog().Errorf("logger discard: %s", buf.String())
-		}
-		buf.Free()
-	}
+	l._Enqueue(l._Record(lv, file, line, debug, formatFunc))
 }
 
 func (l *logger) Outputf(lv Level, prefix string, depth int, format string, args ...interface{}) {
-	if l == nil || !l.file._Check(lv) {
+	if !l._Allow(lv, prefix) {
 		return
 	}
 
 	file, line, debug := l.formatMsg(depth)
 	formatFunc := func(buf *Buffer) {
-		if prefix != "" {
-			_ = buf.WriteByte('[')
-			_, _ = buf.WriteString(prefix)
-			_ = buf.WriteByte(']')
-		}
+		appendPrefix2Buffer(buf, prefix)
 		_, _ = fmt.Fprintf(buf, format, args...)
 	}
-	select {
-	case l.logCh <- logData{
-		lv:     lv,
-		file:   file,
-		line:   line,
-		format: formatFunc,
-		debug:  debug,
-	}:
-	case <-l.closed:
-		buf := l.formatHeaderWithBodyFunction(lv, file, line, formatFunc, debug)
-		if l.std {
-			_, _ = fmt.Fprintf(os.Stderr, "logger discard: %s", buf.String())
-		} else {
-			_StdLog().Errorf("logger discard: %s", buf.String())
+	l._Enqueue(l._Record(lv, file, line, debug, formatFunc))
+}
+
+// OutputFields always renders on the calling goroutine, even with
+// LogAsyncFormat, so the fields are not retained and nothing is boxed.
+func (l *logger) OutputFields(lv Level, prefix string, depth int, msg string, fields ...Field) {
+	if !l._Allow(lv, prefix) {
+		return
+	}
+
+	file, line, debug := l.formatMsg(depth)
+	out := l.formatHeaderWithBodyFunction(lv, file, line, func(buf *Buffer) {
+		appendPrefix2Buffer(buf, prefix)
+		_, _ = buf.WriteString(msg)
+		l._AppendFields(buf, fields)
+	}, debug)
+	l._Enqueue(logData{lv: lv, out: out})
+}
+
+// OutputBody queues a record whose body has already been rendered into body,
+// for adapters that format their own messages. The prefix and level filter of
+// lg apply, file and line name the caller and an empty file leaves it out.
+// body is owned and freed by jlog afterwards.
+func OutputBody(lg Logger, lv Level, file string, line int, body *Buffer) {
+	switch l := lg.(type) {
+	case *logger:
+		l._OutputBody(lv, "", file, line, body)
+	case *CustomLogger:
+		if !l._ControlFlag(lv) {
+			body.Free()
+			return
 		}
-		buf.Free()
+		l._Logger()._OutputBody(lv, l.prefix, file, line, body)
+	default:
+		_Output(lg, lv, "", 0, string(body.Bytes()))
+		body.Free()
+	}
+}
+
+// RedactedKey reports whether lg renders the values of the key fields as
+// Redacted, see LogRedactFields, for adapters that render their own fields.
+func RedactedKey(lg Logger, key string) bool {
+	var l *logger
+	switch lg := lg.(type) {
+	case *logger:
+		l = lg
+	case *CustomLogger:
+		l = lg._Logger()
+	}
+	if l == nil {
+		return false
+	}
+	_, ok := l.redactKeys[key]
+	return ok
+}
+
+func (l *logger) _OutputBody(lv Level, prefix, file string, line int, body *Buffer) {
+	if !l._Allow(lv, prefix) {
+		body.Free()
+		return
+	}
+
+	out := l.formatHeaderWithBodyFunction(lv, file, line, func(buf *Buffer) {
+		appendPrefix2Buffer(buf, prefix)
+		_, _ = buf.Write(body.Bytes())
+	}, file != "")
+	body.Free()
+	l._Enqueue(logData{lv: lv, out: out})
+}
+
+func appendPrefix2Buffer(buf *Buffer, prefix string) {
+	if prefix != "" {
+		_ = buf.WriteByte('[')
+		_, _ = buf.WriteString(prefix)
+		_ = buf.WriteByte(']')
 	}
 }
 
@@ -271,7 +587,7 @@ func (l *logger) formatHeaderWithBodyFunction(lv Level, file string, line int, b
 		tmp [64]byte
 	)
 
-	now = time.Now()
+	now = l.file._Now()
 	if line < 0 {
 		line = 0 // not a real line number, but acceptable to someDigits
 	}
@@ -279,34 +595,54 @@ func (l *logger) formatHeaderWithBodyFunction(lv Level, file string, line int, b
 		lv = INFO // for safety.
 	}
 
-	// Avoid Fprintf, for speed. The format is so simple that we can do it quickly by hand.
-	// It's worth about 3X. Fprintf is hard.
-	year, month, day := now.Date()
-	hour, minute, second := now.Clock()
 	// yyyymmdd hh:mm:ss.uuuuuu [I] file:line
 	buf = l.pool.Get()
+	buf.lv = lv
+	buf.enc.redact = l.redactKeys
 
 	// header
-	nDigits(tmp[:], 4, 0, year, '0')
-	twoDigits(tmp[:], 4, int(month))
-	twoDigits(tmp[:], 6, day)
-	tmp[8] = ' '
-	twoDigits(tmp[:], 9, hour)
-	tmp[11] = ':'
-	twoDigits(tmp[:], 12, minute)
-	tmp[14] = ':'
-	twoDigits(tmp[:], 15, second)
-	tmp[17] = '.'
-	nDigits(tmp[:], 6, 18, now.Nanosecond()/1000, '0')
-	tmp[24] = ' '
-	tmp[25] = '['
-	tmp[26] = LevelFlags[lv]
-	tmp[27] = ']'
-	tmp[28] = ':'
-	_, _ = buf.Write(tmp[:29])
+	switch l.timeLayout {
+	case TimeFormatGlog:
+		// Avoid Fprintf, for speed. The format is so simple that we can do it quickly by hand.
+		// It's worth about 3X. Fprintf is hard.
+		year, month, day := now.Date()
+		hour, minute, second := now.Clock()
+		nDigits(tmp[:], 4, 0, year, '0')
+		twoDigits(tmp[:], 4, int(month))
+		twoDigits(tmp[:], 6, day)
+		tmp[8] = ' '
+		twoDigits(tmp[:], 9, hour)
+		tmp[11] = ':'
+		twoDigits(tmp[:], 12, minute)
+		tmp[14] = ':'
+		twoDigits(tmp[:], 15, second)
+		tmp[17] = '.'
+		nDigits(tmp[:], 6, 18, now.Nanosecond()/1000, '0')
+		_, _ = buf.Write(tmp[:24])
+	case TimeFormatUnixMilli:
+		buf.AppendInt(now.UnixNano() / int64(time.Millisecond))
+	case TimeFormatUnixNano:
+		buf.AppendInt(now.UnixNano())
+	default:
+		buf.AppendTime(now, l.timeLayout)
+	}
+	tmp[0] = ' '
+	tmp[1] = '['
+	tmp[2] = LevelFlags[lv]
+	tmp[3] = ']'
+	tmp[4] = ':'
+	buf.flag = buf.Len() + 1
+	_, _ = buf.Write(tmp[:5])
 
 	// body
+	start := buf.Len()
 	bodyFn(buf)
+	if len(l.scrubbers) > 0 {
+		l._Scrub(buf, start)
+	}
+	if l.multiline != MultilineRaw {
+		l._FoldBody(buf, start)
+	}
 
 	// tail
 	if dev {
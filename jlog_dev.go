@@ -6,20 +6,17 @@
 
 package jlog
 
-import (
-	"runtime"
-	"strings"
-)
-
-func StdLogInit() {
-	stdLog = NewLogger(_LogDebug(true), LogLevel(ERROR), _LogStd(true))
+func _NewStdLog() *logger {
+	return NewLogger(_LogDebug(true), LogLevel(ERROR), _LogStd(true))
 }
 
+func StdLogInit() { _Swap(&stdLog, _NewStdLog()) }
+
 // init gLog params.
 func GLogInit(opts ...Option) {
 	StdLogInit()
 	opts = append(opts, _LogDebug(true))
-	gLog = NewLogger(opts...)
+	SetDefault(NewLogger(opts...))
 }
 
 /*
@@ -39,16 +36,7 @@ where the fields are defined as follows:
 	line             The line number
 */
 func (l *logger) formatMsg(depth int) (string, int, bool) {
-	_, file, line, ok := runtime.Caller(3 + depth)
-	if !ok {
-		file = "???"
-		line = 1
-	} else {
-		slash := strings.LastIndex(file, "/")
-		if slash >= 0 {
-			file = file[slash+1:]
-		}
-	}
+	file, line := caller(5 + depth)
 	return file, line, true
 	// return l.formatHeaderWithBodyFunction(lv, file, line, bodyFn, true)
 }
@@ -6,15 +6,17 @@
 
 package jlog
 
-func StdLogInit() {
-	stdLog = NewLogger(LogLevel(ERROR), _LogStd(true))
+func _NewStdLog() *logger {
+	return NewLogger(LogLevel(ERROR), _LogStd(true))
 }
 
+func StdLogInit() { _Swap(&stdLog, _NewStdLog()) }
+
 // GLogInit
 // init gLog params.
 func GLogInit(opts ...Option) {
 	StdLogInit()
-	gLog = NewLogger(opts...)
+	SetDefault(NewLogger(opts...))
 }
 
 /*
@@ -31,6 +33,10 @@ where the fields are defined as follows:
 	hh:mm:ss.uuuuuu  Time in hours, minutes and fractional seconds
 	msg              The user-supplied message
 */
-func (l *logger) formatMsg(_ int) (string, int, bool) {
-	return "", 0, false
+func (l *logger) formatMsg(depth int) (string, int, bool) {
+	if !l.caller {
+		return "", 0, false
+	}
+	file, line := caller(5 + depth)
+	return file, line, true
 }
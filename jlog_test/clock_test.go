@@ -0,0 +1,115 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/jlogtest"
+)
+
+type closeLogger interface {
+	jlog.Logger
+	Close()
+}
+
+func clockLogger(dir string, clock jlog.Clock) closeLogger {
+	return jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogClock(clock),
+		jlog.LogTimeZone(time.UTC), jlog.LogSync(true))
+}
+
+func readRotated(t *testing.T, dir, hour string, idx int, ext string) string {
+	t.Helper()
+	name := jlog.WithoutExt(filepath.Base(os.Args[0])) + "." + hour + "." + strconv.Itoa(idx) + "." + ext + ".log"
+	data, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("read rotated file: %v", err)
+	}
+	return string(data)
+}
+
+func TestRotateAcrossHour(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlogtest.NewClock(time.Date(2026, 10, 18, 10, 59, 59, 500e6, time.UTC))
+	l := clockLogger(dir, clock)
+	l.Info("before")
+	clock.Set(time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC))
+	l.Info("after")
+	l.Close()
+
+	if got := readRotated(t, dir, "2026101810", 0, "inf"); !strings.HasPrefix(got, "20261018 10:59:59.500000 [I]:before") || strings.Contains(got, "after") {
+		t.Fatalf("10 o'clock file got %q", got)
+	}
+	if got := readRotated(t, dir, "2026101811", 0, "inf"); !strings.HasPrefix(got, "20261018 11:00:00.000000 [I]:after") {
+		t.Fatalf("11 o'clock file got %q", got)
+	}
+	if got := readLog(t, dir, "inf"); !strings.Contains(got, "after") || strings.Contains(got, "before") {
+		t.Fatalf("symlink does not follow the rotation, got %q", got)
+	}
+}
+
+func TestRotateAcrossDay(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlogtest.NewClock(time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC))
+	l := clockLogger(dir, clock)
+	l.Warn("old year")
+	clock.Add(time.Second)
+	l.Warn("new year")
+	l.Close()
+
+	for _, ext := range []string{"inf", "wrn"} {
+		if got := readRotated(t, dir, "2026123123", 0, ext); !strings.Contains(got, "old year") || strings.Contains(got, "new year") {
+			t.Fatalf("%s: last file of the day got %q", ext, got)
+		}
+		if got := readRotated(t, dir, "2027010100", 0, ext); !strings.HasPrefix(got, "20270101 00:00:00.000000 [W]:new year") {
+			t.Fatalf("%s: first file of the day got %q", ext, got)
+		}
+	}
+}
+
+func TestRotateResumeIndex(t *testing.T) {
+	dir := t.TempDir()
+	base := jlog.WithoutExt(filepath.Base(os.Args[0])) + ".2026101810."
+	for _, name := range []string{base + "1.inf.log", base + "3.inf.log", base + "7.err.log"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("old\n"), 0664); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock := jlogtest.NewClock(time.Date(2026, 10, 18, 10, 30, 0, 0, time.UTC))
+	l := clockLogger(dir, clock)
+	l.Info("resumed")
+	l.Error("resumed error")
+	l.Close()
+
+	if got := readRotated(t, dir, "2026101810", 3, "inf"); !strings.HasPrefix(got, "old\n") || !strings.Contains(got, "resumed") {
+		t.Fatalf("did not resume the last index, got %q", got)
+	}
+	if got := readRotated(t, dir, "2026101810", 7, "err"); !strings.HasPrefix(got, "old\n") || !strings.Contains(got, "resumed error") {
+		t.Fatalf("did not resume the err index, got %q", got)
+	}
+
+	// a later run in the same hour appends again, the next hour starts at 0.
+	clock.Add(10 * time.Minute)
+	l = clockLogger(dir, clock)
+	l.Info("second run")
+	clock.Add(time.Hour)
+	l.Info("next hour")
+	l.Close()
+
+	if got := readRotated(t, dir, "2026101810", 3, "inf"); !strings.Contains(got, "second run") {
+		t.Fatalf("second run did not append, got %q", got)
+	}
+	if got := readRotated(t, dir, "2026101811", 0, "inf"); !strings.Contains(got, "next hour") {
+		t.Fatalf("next hour got %q", got)
+	}
+}
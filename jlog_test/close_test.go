@@ -0,0 +1,74 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+func TestCloseIdempotent(t *testing.T) {
+	l := jlog.NewLogger(jlog.LogDir(t.TempDir()), jlog.LogLevel(jlog.ERROR))
+	l.Info("before close")
+	l.Close()
+	l.Close()
+	if err := l.CloseContext(context.Background()); err != nil {
+		t.Fatalf("CloseContext after Close: %v", err)
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync after Close: %v", err)
+	}
+	// falls back to stderr instead of panicking or blocking.
+	l.Error("after close")
+}
+
+func TestCloseContextTimeout(t *testing.T) {
+	l := jlog.NewLogger(jlog.LogDir(t.TempDir()), jlog.LogLevel(jlog.ERROR))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the writer may or may not have finished, but a done context must not block.
+	if err := l.CloseContext(ctx); err != nil && err != context.Canceled {
+		t.Fatalf("CloseContext: %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := l.CloseContext(ctx); err != nil {
+		t.Fatalf("second CloseContext: %v", err)
+	}
+}
+
+func TestConcurrentCloseAndOutput(t *testing.T) {
+	l := jlog.NewLogger(jlog.LogDir(t.TempDir()), jlog.LogLevel(jlog.ERROR))
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			for j := 0; j < 50; j++ {
+				l.Infof("worker %d record %d", i, j)
+			}
+			_ = l.Sync()
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			time.Sleep(time.Millisecond)
+			l.Close()
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
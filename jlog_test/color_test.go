@@ -0,0 +1,56 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+)
+
+func TestColorNeverInFiles(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogColor(true))
+	l.Warn("plain warn")
+	l.Error("plain error")
+	l.Close()
+
+	for _, ext := range []string{"inf", "wrn", "err"} {
+		got := readLog(t, dir, ext)
+		if !strings.Contains(got, "plain") {
+			t.Fatalf("%s: missing records, got %q", ext, got)
+		}
+		if strings.Contains(got, "\x1b[") {
+			t.Fatalf("%s: escape codes written to file: %q", ext, got)
+		}
+	}
+}
+
+func TestColorAutoOnPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	jlog.StdLogInit()
+	os.Stdout = stdout
+	defer jlog.StdLogInit()
+
+	jlog.DebugStdLog().Warn("no color on a pipe")
+	jlog.CloseStdLog()
+	_ = w.Close()
+
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "[W]:no color on a pipe") {
+		t.Fatalf("got %q", got)
+	}
+}
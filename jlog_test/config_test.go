@@ -0,0 +1,262 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/jlogtest"
+)
+
+func TestConfigValidate(t *testing.T) {
+	for _, tt := range []struct {
+		cfg   jlog.Config
+		field string
+	}{
+		{jlog.Config{}, "dir"},
+		{jlog.Config{Dir: "d", Level: "LOUD"}, "level"},
+		{jlog.Config{Dir: "d", Level: "debug"}, "level"},
+		{jlog.Config{Dir: "d", PrefixLevels: map[string]string{"x": "DEBUG"}}, "prefixLevels.x"},
+		{jlog.Config{Dir: "d", PrefixLevels: map[string]string{"net": "WARN", "db": "nope"}}, "prefixLevels.db"},
+		{jlog.Config{Dir: "d", Rotation: jlog.RotationConfig{MaxSize: -1}}, "rotation.maxSize"},
+		{jlog.Config{Dir: "d", Retention: jlog.RetentionConfig{MaxAge: "7 days"}}, "retention.maxAge"},
+		{jlog.Config{Dir: "d", Retention: jlog.RetentionConfig{MaxFiles: -2}}, "retention.maxFiles"},
+		{jlog.Config{Dir: "d", Encoder: "xml"}, "encoder"},
+		{jlog.Config{Dir: "d", TimeZone: "Mars/Olympus"}, "timeZone"},
+		{jlog.Config{Dir: "d", Multiline: "fold"}, "multiline"},
+		{jlog.Config{Dir: "d", Sinks: []jlog.SinkConfig{{Level: "WARN", Path: "stderr"}, {Level: "x", Path: "stdout"}}}, "sinks[1].level"},
+		{jlog.Config{Console: true, Sinks: []jlog.SinkConfig{{Level: "WARN"}}}, "sinks[0].path"},
+	} {
+		err := tt.cfg.Validate()
+		var ce *jlog.ConfigError
+		if !errors.As(err, &ce) || ce.Field != tt.field {
+			t.Fatalf("Validate(%+v) = %v, want an error on %s", tt.cfg, err, tt.field)
+		}
+		if !strings.Contains(err.Error(), tt.field) {
+			t.Fatalf("error %q does not name %s", err, tt.field)
+		}
+	}
+
+	ok := jlog.Config{Dir: "d", Level: "warn", Encoder: "text", TimeZone: "UTC", Retention: jlog.RetentionConfig{MaxAge: "168h"}}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
+
+func setenv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for k, v := range env {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		for k := range env {
+			_ = os.Unsetenv(k)
+		}
+	})
+}
+
+func TestConfigApplyEnv(t *testing.T) {
+	setenv(t, map[string]string{
+		"JLOG_DIR":           "/var/log/app",
+		"JLOG_LEVEL":         "ERROR",
+		"JLOG_CONSOLE":       "true",
+		"JLOG_MAX_SIZE":      "1024",
+		"JLOG_MAX_AGE":       "24h",
+		"JLOG_PREFIX_LEVELS": "net=WARN, db=INFO",
+		"JLOG_SINKS":         "ERROR=stderr",
+	})
+	shared := []jlog.SinkConfig{{Level: "WARN", Path: "stdout"}}
+	cfg := jlog.Config{Dir: "from-file", Level: "INFO", Caller: true, Sinks: shared}
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatalf("ApplyEnv: %v", err)
+	}
+	if shared[0] != (jlog.SinkConfig{Level: "WARN", Path: "stdout"}) {
+		t.Fatalf("ApplyEnv overwrote the sinks of the caller: %+v", shared)
+	}
+	if cfg.Dir != "/var/log/app" || cfg.Level != "ERROR" || !cfg.Console || !cfg.Caller ||
+		cfg.Rotation.MaxSize != 1024 || cfg.Retention.MaxAge != "24h" ||
+		cfg.PrefixLevels["net"] != "WARN" || cfg.PrefixLevels["db"] != "INFO" ||
+		len(cfg.Sinks) != 1 || cfg.Sinks[0] != (jlog.SinkConfig{Level: "ERROR", Path: "stderr"}) {
+		t.Fatalf("ApplyEnv got %+v", cfg)
+	}
+
+	for name, value := range map[string]string{"JLOG_MAX_SIZE": "1GB", "JLOG_CALLER": "maybe", "JLOG_SINKS": "stderr"} {
+		setenv(t, map[string]string{name: value})
+		var ce *jlog.ConfigError
+		if err := cfg.ApplyEnv(); !errors.As(err, &ce) || ce.Field != name {
+			t.Fatalf("ApplyEnv with %s=%s: %v", name, value, err)
+		}
+		_ = os.Unsetenv(name)
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	sink := filepath.Join(t.TempDir(), "sink.log")
+	cfgFile := filepath.Join(dir, "jlog.json")
+	err := ioutil.WriteFile(cfgFile, []byte(`{
+		"dir": "`+filepath.ToSlash(dir)+`",
+		"level": "ERROR",
+		"prefixLevels": {"quiet": "INFO"},
+		"caller": true,
+		"sinks": [{"level": "WARN", "path": "`+filepath.ToSlash(sink)+`"}]
+	}`), 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := jlog.LoadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	l, err := jlog.NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewFromConfig: %v", err)
+	}
+	l.Output(jlog.INFO, "quiet", 0, "quiet info")
+	l.Output(jlog.WARN, "quiet", 0, "quiet warn")
+	l.Output(jlog.WARN, "loud", 0, "loud warn")
+	l.Info("plain info")
+	l.Close()
+
+	got := readLog(t, dir, "inf")
+	if !strings.Contains(got, "[quiet]quiet info") || !strings.Contains(got, "[loud]loud warn") || strings.Contains(got, "quiet warn") {
+		t.Fatalf("prefix levels not applied, got %q", got)
+	}
+	if !strings.Contains(got, "plain info  [config_test.go:") {
+		t.Fatalf("caller missing, got %q", got)
+	}
+	data, err := ioutil.ReadFile(sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); !strings.Contains(s, "loud warn") || strings.Contains(s, "info") {
+		t.Fatalf("sink got %q", s)
+	}
+
+	if _, err = jlog.LoadConfig(writeFile(t, `{"dir": "x", "levle": "INFO"}`)); err == nil || !strings.Contains(err.Error(), "levle") {
+		t.Fatalf("LoadConfig with an unknown field: %v", err)
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jlog.yaml")
+	err := ioutil.WriteFile(path, []byte(`
+dir: /var/log/game
+level: WARN
+prefixLevels:
+  db: ERROR
+retention:
+  maxAge: 168h
+  maxFiles: 10
+multiline: escape
+sinks:
+  - level: ERROR
+    path: stderr
+`), 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := jlog.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	want := jlog.Config{
+		Dir:          "/var/log/game",
+		Level:        "WARN",
+		PrefixLevels: map[string]string{"db": "ERROR"},
+		Retention:    jlog.RetentionConfig{MaxAge: "168h", MaxFiles: 10},
+		Multiline:    jlog.MultilineEscape,
+		Sinks:        []jlog.SinkConfig{{Level: "ERROR", Path: "stderr"}},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("LoadConfig = %+v, want %+v", cfg, want)
+	}
+
+	bad := filepath.Join(t.TempDir(), "jlog.yml")
+	if err = ioutil.WriteFile(bad, []byte("dir: x\nlevle: INFO\n"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = jlog.LoadConfig(bad); err == nil || !strings.Contains(err.Error(), "levle") {
+		t.Fatalf("LoadConfig with an unknown field: %v", err)
+	}
+}
+
+func TestLoadConfigField(t *testing.T) {
+	for _, tt := range []struct{ name, content, field string }{
+		{"jlog.yaml", "dir: x\nlevle: INFO\n", "levle"},
+		{"jlog.yaml", "dir: x\nrotation:\n  maxSize: big\n", "rotation.maxSize"},
+		{"jlog.yml", "dir: x\nsinks:\n  - level: ERROR\n    path: stderr\n  - level: WARN\n    path: [a]\n", "sinks[1].path"},
+		{"jlog.yml", "dir: x\nsinks:\n  - level: ERROR\n    pth: stderr\n", "sinks[0].pth"},
+		{"jlog.json", `{"dir": "x", "levle": "INFO"}`, "levle"},
+		{"jlog.json", `{"dir": "x", "rotation": {"maxSize": "big"}}`, "rotation.maxSize"},
+	} {
+		path := filepath.Join(t.TempDir(), tt.name)
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0664); err != nil {
+			t.Fatal(err)
+		}
+		_, err := jlog.LoadConfig(path)
+		var ce *jlog.ConfigError
+		if !errors.As(err, &ce) || ce.Field != tt.field {
+			t.Errorf("LoadConfig(%q) = %v, want a *ConfigError of %s", tt.content, err, tt.field)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "jlog.yaml")
+	if err := ioutil.WriteFile(path, []byte("dir: [x\n"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jlog.LoadConfig(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Fatalf("LoadConfig with a syntax error: %v", err)
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	if err := ioutil.WriteFile(path, []byte(content), 0664); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlogtest.NewClock(time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC))
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.INFO), jlog.LogClock(clock),
+		jlog.LogTimeZone(time.UTC), jlog.LogSync(true), jlog.LogRetention(3*time.Hour, 2))
+	for i := 0; i < 4; i++ {
+		l.Info("hour")
+		clock.Add(time.Hour)
+	}
+	l.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.inf.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || !strings.Contains(files[0], ".2026101810.") || !strings.Contains(files[1], ".2026101811.") {
+		t.Fatalf("maxFiles kept %v", files)
+	}
+
+	// a day later only maxAge applies, which removes everything but the new file.
+	clock.Add(24 * time.Hour)
+	l = jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.INFO), jlog.LogClock(clock),
+		jlog.LogTimeZone(time.UTC), jlog.LogSync(true), jlog.LogRetention(3*time.Hour, 0))
+	l.Info("next day")
+	l.Close()
+	if files, _ = filepath.Glob(filepath.Join(dir, "*.inf.log")); len(files) != 1 || !strings.Contains(files[0], ".2026101912.") {
+		t.Fatalf("maxAge kept %v", files)
+	}
+}
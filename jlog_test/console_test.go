@@ -0,0 +1,65 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+)
+
+func TestConsoleTee(t *testing.T) {
+	dir := t.TempDir()
+	var info, errs bytes.Buffer
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR),
+		jlog.LogConsoleWriter(jlog.INFO, &info),
+		jlog.LogConsoleWriter(jlog.WARN, ioutil.Discard),
+		jlog.LogConsoleWriter(jlog.ERROR, &errs))
+	l.Info("tee info")
+	l.Warn("tee warn")
+	l.Error("tee error")
+	l.Close()
+
+	if got := info.String(); !strings.Contains(got, "[I]:tee info") || strings.Contains(got, "tee warn") || strings.Contains(got, "tee error") {
+		t.Fatalf("info console got %q", got)
+	}
+	if got := errs.String(); !strings.Contains(got, "[E]:tee error") || strings.Contains(got, "tee info") {
+		t.Fatalf("error console got %q", got)
+	}
+	if strings.Contains(info.String()+errs.String(), "\x1b[") {
+		t.Fatal("escape codes written to a writer that is not a terminal")
+	}
+	got := readLog(t, dir, "inf")
+	for _, msg := range []string{"tee info", "tee warn", "tee error"} {
+		if !strings.Contains(got, msg) {
+			t.Fatalf("file missing %q, got %q", msg, got)
+		}
+	}
+}
+
+func TestConsoleOnly(t *testing.T) {
+	var out bytes.Buffer
+	l := jlog.NewLogger(jlog.LogLevel(jlog.ERROR), jlog.LogConsoleWriter(jlog.WARN, &out), jlog.LogSync(true))
+	l.Warn("console only")
+	if got := out.String(); !strings.Contains(got, "[W]:console only") {
+		t.Fatalf("got %q", got)
+	}
+	l.Close()
+}
+
+func TestConsoleColor(t *testing.T) {
+	var out bytes.Buffer
+	l := jlog.NewLogger(jlog.LogLevel(jlog.ERROR), jlog.LogConsoleWriter(jlog.ERROR, &out), jlog.LogColor(true))
+	l.Error("red")
+	l.Close()
+
+	got := out.String()
+	if !strings.Contains(got, "\x1b[1;31m[E]\x1b[0m:\x1b[31mred") || !strings.HasSuffix(got, "\x1b[0m\n") {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+func readLog(t *testing.T, dir, ext string) string {
+	t.Helper()
+	name := jlog.WithoutExt(filepath.Base(os.Args[0])) + "." + ext
+	data, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("read log: %v", err)
+	}
+	return string(data)
+}
+
+func TestSetDefault(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	prefixed := jlog.NewLogByPrefix("swap")
+
+	jlog.SetDefault(jlog.NewLogger(jlog.LogDir(first), jlog.LogLevel(jlog.ERROR)))
+	jlog.Info("to first")
+	prefixed.Info("prefixed first")
+
+	jlog.SetDefault(jlog.NewLogger(jlog.LogDir(second), jlog.LogLevel(jlog.ERROR)))
+	jlog.Info("to second")
+	prefixed.Info("prefixed second")
+	jlog.CloseGLog()
+
+	got := readLog(t, first, "inf")
+	if !strings.Contains(got, "to first") || !strings.Contains(got, "[swap]prefixed first") {
+		t.Fatalf("replaced logger was not drained, got %q", got)
+	}
+	if strings.Contains(got, "second") {
+		t.Fatalf("record written to replaced logger: %q", got)
+	}
+	got = readLog(t, second, "inf")
+	if !strings.Contains(got, "to second") || !strings.Contains(got, "[swap]prefixed second") {
+		t.Fatalf("default logger missed records, got %q", got)
+	}
+}
+
+func TestSetDefaultWhileLogging(t *testing.T) {
+	const goroutines, records = 16, 2000
+	dirs := make([]string, 20)
+	for i := range dirs {
+		dirs[i] = t.TempDir()
+	}
+	jlog.SetDefault(jlog.NewLogger(jlog.LogDir(dirs[0]), jlog.LogLevel(jlog.ERROR)))
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				jlog.Infof("g%d r%d", g, i)
+			}
+		}(g)
+	}
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	for _, dir := range dirs[1:] {
+		select {
+		case <-done:
+		case <-time.After(time.Millisecond):
+		}
+		jlog.SetDefault(jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR)))
+	}
+	<-done
+	jlog.CloseGLog()
+
+	var got string
+	for _, dir := range dirs {
+		got += readLog(t, dir, "inf")
+	}
+	if n := strings.Count(got, "\n"); n != goroutines*records {
+		t.Fatalf("logged %d lines, want %d", n, goroutines*records)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+func TestFields(t *testing.T) {
+	at := time.Date(2021, 6, 1, 8, 30, 0, 500, time.UTC)
+	buf := &jlog.Buffer{}
+	jlog.DebugFieldsAppend(buf,
+		jlog.Int("hp", -3), jlog.Uint64("id", 1<<63), jlog.Bool("dead", true),
+		jlog.Float64("x", 1.25), jlog.Str("name", "tiger"), jlog.Str("say", "hi there"),
+		jlog.Str("empty", ""), jlog.Dur("cd", 1500*time.Millisecond), jlog.Time("at", at),
+		jlog.Err(errors.New("boom")), jlog.Err(nil), jlog.Any("pos", []int{1, 2}))
+
+	want := ` hp=-3 id=9223372036854775808 dead=true x=1.25 name=tiger say="hi there" empty=""` +
+		` cd=1.5s at=2021-06-01T08:30:00.0000005Z error=boom error=<nil> pos=[1,2]`
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+
+	for _, d := range []time.Duration{0, 1, 1500, -2 * time.Millisecond, 90 * time.Minute, 1<<63 - 1} {
+		buf.Reset()
+		jlog.DebugFieldsAppend(buf, jlog.Dur("d", d))
+		if got := buf.String(); got != " d="+d.String() {
+			t.Fatalf("Dur(%d) rendered %q", d, got)
+		}
+	}
+
+	// past the range of UnixNano
+	for _, at := range []time.Time{{}, time.Date(3000, 1, 2, 3, 4, 5, 6, time.UTC), time.Date(1600, 1, 1, 0, 0, 0, 0, time.FixedZone("X", 3600))} {
+		buf.Reset()
+		jlog.DebugFieldsAppend(buf, jlog.Time("at", at))
+		if got, want := buf.String(), " at="+at.Format(time.RFC3339Nano); got != want {
+			t.Fatalf("Time(%v) rendered %q, want %q", at, got, want)
+		}
+	}
+}
+
+func TestInfow(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR))
+	l.Warnw("tick", jlog.Int("hp", 10), jlog.Str("who", "p1"))
+	l.Close()
+
+	if got := readLog(t, dir, "wrn"); !strings.Contains(got, "]:tick hp=10 who=p1") {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
+
+func Benchmark_DebugFieldsAppend(b *testing.B) {
+	var buffer = &jlog.Buffer{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		jlog.DebugFieldsAppend(buffer,
+			jlog.Str("a", "asdasd"), jlog.Int("b", 13), jlog.Str("c", "asdasda"),
+			jlog.Int("d", 28), jlog.Dur("e", time.Second), jlog.Int("f", 334))
+	}
+}
+
+func Benchmark_Infow(b *testing.B) {
+	l := jlog.NewLogger(jlog.LogDir(b.TempDir()), jlog.LogLevel(jlog.ERROR))
+	defer l.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Infow("tick", jlog.Int("hp", i), jlog.Str("who", "p1"), jlog.Dur("cost", time.Millisecond))
+	}
+}
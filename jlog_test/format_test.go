@@ -0,0 +1,65 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+)
+
+type position struct {
+	X, Y int
+}
+
+// The arguments are rendered before the log call returns, so changing them
+// right after neither alters the record nor races with the writer (-race).
+func TestFormatOnCaller(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR))
+
+	items := []int{1, 2, 3}
+	scores := map[string]int{"a": 1}
+	pos := &position{1, 2}
+	l.Info("items", items, scores, pos)
+	l.Infof("pos %v", *pos)
+	items[0], scores["a"], pos.X = 100, 100, 100
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			own := []int{i, i}
+			for j := 0; j < 100; j++ {
+				l.Info("worker", own)
+				own[1] = j + 1
+			}
+		}(i)
+	}
+	wg.Wait()
+	l.Close()
+
+	got := readLog(t, dir, "inf")
+	for _, want := range []string{"[1,2,3]", `{"a":1}`, `{"X":1,"Y":2}`, "pos {1 2}", "[3,3]", "[3,99]"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("record changed after the log call, %q not in %q", want, got)
+		}
+	}
+}
+
+func TestAsyncFormat(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogAsyncFormat(true))
+	l.Info("async", 1)
+	l.Warnw("fields", jlog.Int("n", 2))
+	l.Close()
+
+	if got := readLog(t, dir, "inf"); !strings.Contains(got, "async 1") || !strings.Contains(got, "fields n=2") {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
@@ -3,9 +3,10 @@ package jlog_test
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
-	"github.com/tiger-game/tiger/jlog"
+	"github.com/tiger-game/jlog"
 )
 
 type Person struct {
@@ -23,6 +24,23 @@ func TestJlog(t *testing.T) {
 	jlog.Info(errors.New("Test Print Error"))
 }
 
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR))
+	defer l.Close()
+
+	for i := 0; i < 100; i++ {
+		l.Infof("sync record %d", i)
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	if n := strings.Count(readLog(t, dir, "inf"), "sync record"); n != 100 {
+		t.Fatalf("got %d records after Sync, want 100", n)
+	}
+}
+
 var args = []interface{}{
 	13, 28, 334,
 }
@@ -0,0 +1,47 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+)
+
+func TestLazy(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.WARN))
+	cl := jlog.NewLogByPrefixLevel("lazy", jlog.INFO)
+
+	if l.Enabled(jlog.DEBUG) || l.Enabled(jlog.ERROR) || !l.Enabled(jlog.WARN) {
+		t.Fatalf("Enabled does not follow the level")
+	}
+	if cl.(jlog.LevelEnabler).Enabled(jlog.WARN) {
+		t.Fatalf("CustomLogger Enabled ignores its own level")
+	}
+
+	calls := 0
+	value := jlog.Lazy(func() interface{} { calls++; return 42 })
+	l.Debug("dump", value)
+	l.Errorf("dump %v", value)
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("Lazy evaluated %d times for dropped records", calls)
+	}
+
+	l.Warn("dump", value)
+	l.Warnf("padded %05d|%-4v|", value, value)
+	l.Close()
+	if calls != 3 {
+		t.Fatalf("Lazy evaluated %d times, want 3", calls)
+	}
+	got := readLog(t, dir, "wrn")
+	if !strings.Contains(got, "dump 42 ") || !strings.Contains(got, "padded 00042|42  |") {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+type pos struct{ x, y int64 }
+
+func (p pos) MarshalLog(enc jlog.ObjectEncoder) {
+	enc.AddInt64("x", p.x)
+	enc.AddInt64("y", p.y)
+}
+
+type entity struct {
+	id   uint64
+	name string
+	pos  pos
+	ttl  time.Duration
+	tags []string
+}
+
+func (e *entity) MarshalLog(enc jlog.ObjectEncoder) {
+	enc.AddUint64("id", e.id)
+	enc.AddString("name", e.name)
+	enc.AddObject("pos", e.pos)
+	enc.AddDuration("ttl", e.ttl)
+	enc.AddAny("tags", e.tags)
+	enc.AddBool("alive", true)
+}
+
+type room struct{ id int }
+
+func (r *room) String() string { return "room#" + string(rune('0'+r.id)) }
+
+type panicky struct{}
+
+func (panicky) String() string { panic("boom") }
+
+func appendArg(arg interface{}) string {
+	buf := jlog.NewPool().Get()
+	defer buf.Free()
+	jlog.DebugBufferAppend(buf, arg)
+	return buf.String()
+}
+
+func TestLogMarshaler(t *testing.T) {
+	e := &entity{id: 7, name: "bob smith", pos: pos{1, 2}, ttl: 1500 * time.Millisecond, tags: []string{"a"}}
+	for _, tt := range []struct {
+		arg  interface{}
+		want string
+	}{
+		{e, `{id=7 name="bob smith" pos={x=1 y=2} ttl=1.5s tags=["a"] alive=true}`},
+		{pos{}, `{x=0 y=0}`},
+		{(*entity)(nil), `<nil>`},
+		{&room{id: 3}, `room#3`},
+		{(*room)(nil), `<nil>`},
+		{panicky{}, `%!v(PANIC=String method: boom)`},
+		{struct{ X int }{1}, `{"X":1}`},
+	} {
+		if got := appendArg(tt.arg); got != tt.want {
+			t.Errorf("append %#v: got %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+
+	buf := jlog.NewPool().Get()
+	defer buf.Free()
+	jlog.DebugFieldsAppend(buf, jlog.Object("who", e.pos), jlog.Any("room", &room{id: 1}))
+	if got := buf.String(); got != " who={x=1 y=2} room=room#1" {
+		t.Fatalf("fields got %q", got)
+	}
+}
+
+func Benchmark_LogMarshaler(b *testing.B) {
+	var p interface{} = pos{1, 2}
+	f := jlog.Object("p", pos{3, 4})
+	buf := jlog.NewPool().Get()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		jlog.DebugBufferAppend(buf, p)
+		jlog.DebugFieldsAppend(buf, f)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+)
+
+var multilineBodies = []string{
+	"single line",
+	"open config: permission denied\n\tat main.go:10\n\tat init.go:3",
+	"windows\r\nline\r\n",
+	"trailing break\n",
+	"\n\nleading breaks",
+	"tab\tand back\\slash \\n not a break",
+	"controls \x00\x07\x1b[31m\x7f",
+	"fake\n" + jlog.ContinuationMarker + "marker\n20211018 10:00:00.000000 [E]:fake header",
+	"",
+}
+
+var callerTail = regexp.MustCompile(` \[[^ \]]+:\d+\]$`)
+
+// multilineRecords logs the bodies to a log dir and returns the text of the
+// info file, a record per body.
+func multilineRecords(t *testing.T, mode string) string {
+	t.Helper()
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogMultiline(mode))
+	for _, body := range multilineBodies {
+		l.Infof("%s", body)
+	}
+	l.Close()
+	return readLog(t, dir, "inf")
+}
+
+// recordBody strips the header and the dev caller of a record.
+func recordBody(t *testing.T, record string) string {
+	t.Helper()
+	i := strings.Index(record, "]:")
+	if i < 0 {
+		t.Fatalf("no header in %q", record)
+	}
+	return callerTail.ReplaceAllString(record[i+2:], "")
+}
+
+func TestMultilineEscape(t *testing.T) {
+	lines := strings.Split(strings.TrimSuffix(multilineRecords(t, jlog.MultilineEscape), "\n"), "\n")
+	if len(lines) != len(multilineBodies) {
+		t.Fatalf("got %d lines, want a line per record: %q", len(lines), lines)
+	}
+	for i, line := range lines {
+		if strings.ContainsAny(line, "\r\t\x00\x1b\x7f") {
+			t.Errorf("line %d holds a control character: %q", i, line)
+		}
+		body, err := jlog.UnescapeBody(recordBody(t, line))
+		if err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if body != multilineBodies[i] {
+			t.Errorf("round trip %d: got %q, want %q", i, body, multilineBodies[i])
+		}
+	}
+}
+
+func TestMultilineIndent(t *testing.T) {
+	var records [][]string
+	for _, line := range strings.Split(strings.TrimSuffix(multilineRecords(t, jlog.MultilineIndent), "\n"), "\n") {
+		if strings.HasPrefix(line, jlog.ContinuationMarker) {
+			if len(records) == 0 {
+				t.Fatalf("continuation line %q without a record", line)
+			}
+			records[len(records)-1] = append(records[len(records)-1], line)
+			continue
+		}
+		records = append(records, []string{line})
+	}
+	if len(records) != len(multilineBodies) {
+		t.Fatalf("got %d records, want %d", len(records), len(multilineBodies))
+	}
+	for i, lines := range records {
+		// the caller ends the last line of the record.
+		lines[0] = recordBody(t, lines[0])
+		last := len(lines) - 1
+		lines[last] = callerTail.ReplaceAllString(lines[last], "")
+		if body := jlog.JoinContinuation(lines); body != multilineBodies[i] {
+			t.Errorf("round trip %d: got %q, want %q", i, body, multilineBodies[i])
+		}
+	}
+}
+
+func TestMultilineRaw(t *testing.T) {
+	for _, mode := range []string{jlog.MultilineRaw, "", "unknown"} {
+		if got := multilineRecords(t, mode); !strings.Contains(got, "denied\n\tat main.go:10\n") {
+			t.Fatalf("mode %q: raw body rewritten: %q", mode, got)
+		}
+	}
+}
+
+func TestUnescapeBody(t *testing.T) {
+	for _, s := range []string{`\`, `\q`, `\x4`, `\xzz`} {
+		if _, err := jlog.UnescapeBody(s); err == nil {
+			t.Errorf("UnescapeBody(%q) succeeded", s)
+		}
+	}
+	if got, err := jlog.UnescapeBody(`a\x41\\`); err != nil || got != `aA\` {
+		t.Errorf("UnescapeBody = %q, %v", got, err)
+	}
+}
+
+func Benchmark_MultilineEscape(b *testing.B) {
+	l := jlog.NewLogger(jlog.LogLevel(jlog.ERROR), jlog.LogConsoleWriter(jlog.INFO, ioutil.Discard),
+		jlog.LogMultiline(jlog.MultilineEscape))
+	defer l.Close()
+	err := fmt.Errorf("open config: %w", fmt.Errorf("permission denied\n\tat main.go:10"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info(err)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+// gateWriter blocks the writes until open is closed.
+type gateWriter struct {
+	open chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.open
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+// TestFullQueue parks the producers on a queue the stalled writer goroutine
+// cannot drain, none of the records may be lost once it goes on.
+func TestFullQueue(t *testing.T) {
+	const producers, records = 64, 100
+	w := &gateWriter{open: make(chan struct{})}
+	l := jlog.NewLogger(jlog.LogLevel(jlog.ERROR), jlog.LogConsoleWriter(jlog.INFO, w))
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				l.Infof("p%d r%d", p, i)
+			}
+		}(p)
+	}
+	time.Sleep(50 * time.Millisecond) // let the queue fill up
+	close(w.open)
+	wg.Wait()
+	l.Close()
+
+	got := w.buf.String()
+	for p := 0; p < producers; p++ {
+		for i := 0; i < records; i++ {
+			if !strings.Contains(got, fmt.Sprintf("p%d r%d\n", p, i)) && !strings.Contains(got, fmt.Sprintf("p%d r%d [", p, i)) {
+				t.Fatalf("record p%d r%d lost", p, i)
+			}
+		}
+	}
+}
+
+// chanLogger is the queue of the logger before the ring, a channel of 64
+// records read by a goroutine writing them one by one to a buffered file,
+// the baseline of benchOutput.
+type chanLogger struct {
+	ch   chan *jlog.Buffer
+	done chan struct{}
+	pool jlog.Pool
+}
+
+func newChanLogger(path string) (*chanLogger, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &chanLogger{ch: make(chan *jlog.Buffer, 1<<6), done: make(chan struct{}), pool: jlog.NewPool()}
+	go func() {
+		bw := bufio.NewWriterSize(f, 256<<10)
+		for buf := range c.ch {
+			_, _ = bw.Write(buf.Bytes())
+			buf.Free()
+		}
+		_ = bw.Flush()
+		_ = f.Close()
+		close(c.done)
+	}()
+	return c, nil
+}
+
+func (c *chanLogger) Info(args ...interface{}) {
+	buf := c.pool.Get()
+	buf.AppendTime(time.Now(), "20060102 15:04:05.000000")
+	_, _ = buf.WriteString(" [I]:")
+	for _, arg := range args {
+		jlog.DebugBufferAppend(buf, arg)
+	}
+	_ = buf.WriteByte('\n')
+	c.ch <- buf
+}
+
+func (c *chanLogger) Close() {
+	close(c.ch)
+	<-c.done
+}
+
+type infoLogger interface {
+	Info(args ...interface{})
+	Close()
+}
+
+// benchOutput logs b.N records from producers goroutines to a file in dir
+// through the writer goroutine of the logger newLogger returns, reports the
+// throughput as ns/op and the percentiles of the time a log call takes.
+func benchOutput(b *testing.B, producers int, newLogger func(dir string) infoLogger) {
+	dir := b.TempDir()
+	l := newLogger(dir)
+	lat := make([][]time.Duration, producers)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		n := b.N / producers
+		if p < b.N%producers {
+			n++
+		}
+		lat[p] = make([]time.Duration, 0, n)
+		wg.Add(1)
+		go func(p, n int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				start := time.Now()
+				l.Info("player", i, "moved to", 12.5, true)
+				lat[p] = append(lat[p], time.Since(start))
+			}
+		}(p, n)
+	}
+	wg.Wait()
+	l.Close()
+	b.StopTimer()
+
+	var all []time.Duration
+	for _, d := range lat {
+		all = append(all, d...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	b.ReportMetric(float64(all[len(all)/2]), "p50-ns")
+	b.ReportMetric(float64(all[len(all)*99/100]), "p99-ns")
+
+	if files, _ := ioutil.ReadDir(dir); len(files) == 0 {
+		b.Fatalf("nothing written to %s", filepath.Base(dir))
+	}
+}
+
+func ringLogger(dir string) infoLogger {
+	return jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.INFO))
+}
+
+func channelLogger(b *testing.B) func(dir string) infoLogger {
+	return func(dir string) infoLogger {
+		c, err := newChanLogger(filepath.Join(dir, "chan.log"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		return c
+	}
+}
+
+func Benchmark_Output1(b *testing.B)         { benchOutput(b, 1, ringLogger) }
+func Benchmark_Output8(b *testing.B)         { benchOutput(b, 8, ringLogger) }
+func Benchmark_Output64(b *testing.B)        { benchOutput(b, 64, ringLogger) }
+func Benchmark_ChannelOutput1(b *testing.B)  { benchOutput(b, 1, channelLogger(b)) }
+func Benchmark_ChannelOutput8(b *testing.B)  { benchOutput(b, 8, channelLogger(b)) }
+func Benchmark_ChannelOutput64(b *testing.B) { benchOutput(b, 64, channelLogger(b)) }
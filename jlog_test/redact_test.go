@@ -0,0 +1,127 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+type Contact struct {
+	Email string `json:"email" jlog:"redact"`
+	Phone int64  `jlog:"redact"`
+}
+
+type Account struct {
+	ID int
+}
+
+type Player struct {
+	Account
+	Name    string            `json:"name"`
+	Token   *string           `json:"token,omitempty" jlog:"redact"`
+	Contact *Contact          `json:"contact"`
+	Friends []Contact         `json:"friends,omitempty"`
+	Skip    string            `json:"-"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	secret  string
+}
+
+func consoleRecord(t *testing.T, opts []jlog.Option, log func(l jlog.Logger)) string {
+	t.Helper()
+	var out strings.Builder
+	opts = append(opts, jlog.LogLevel(jlog.ERROR), jlog.LogConsoleWriter(jlog.INFO, &out), jlog.LogSync(true))
+	l := jlog.NewLogger(opts...)
+	log(l)
+	l.Close()
+	got := out.String()
+	if i := strings.Index(got, "]:"); i >= 0 {
+		got = got[i+2:]
+	}
+	if i := strings.LastIndex(got, " ["); i >= 0 && strings.HasSuffix(got, "]\n") {
+		got = got[:i] // the dev caller
+	}
+	// Info separates the args with a trailing space.
+	return strings.TrimSuffix(strings.TrimSuffix(got, "\n"), " ")
+}
+
+func TestRedactTag(t *testing.T) {
+	token := "t0k3n"
+	p := Player{
+		Account: Account{ID: 7},
+		Name:    "bob",
+		Token:   &token,
+		Contact: &Contact{Email: "bob@example.com", Phone: 5551234},
+		Friends: []Contact{{Email: "amy@example.com"}},
+		Skip:    "skipped",
+		Tags:    map[string]string{"z": "1", "a": "2"},
+		secret:  "hidden",
+	}
+	got := consoleRecord(t, nil, func(l jlog.Logger) { l.Info(p) })
+	want := `{"ID":7,"name":"bob","token":"[REDACTED]","contact":{"email":"[REDACTED]","Phone":"[REDACTED]"},` +
+		`"friends":[{"email":"[REDACTED]","Phone":"[REDACTED]"}],"tags":{"a":"2","z":"1"}}`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	got = consoleRecord(t, nil, func(l jlog.Logger) {
+		l.Info(map[string]interface{}{"player": &Contact{Email: "x@y.z"}, "n": 1})
+	})
+	if got != `{"n":1,"player":{"email":"[REDACTED]","Phone":"[REDACTED]"}}` {
+		t.Fatalf("interface values not redacted, got %s", got)
+	}
+
+	got = consoleRecord(t, nil, func(l jlog.Logger) { l.(jlog.FieldLogger).Infow("login", jlog.Any("who", Contact{Email: "x@y.z"})) })
+	if strings.Contains(got, "x@y.z") {
+		t.Fatalf("Any field not redacted, got %s", got)
+	}
+}
+
+func TestRedactFields(t *testing.T) {
+	got := consoleRecord(t, []jlog.Option{jlog.LogRedactFields("password", "token")}, func(l jlog.Logger) {
+		l.(jlog.FieldLogger).Infow("login", jlog.Str("user", "bob"), jlog.Str("password", "hunter2"), jlog.Int("token", 42))
+	})
+	if got != "login user=bob password=[REDACTED] token=[REDACTED]" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestRedactObjectKeys(t *testing.T) {
+	opts := []jlog.Option{jlog.LogRedactFields("name", "y")}
+	e := &entity{id: 3, name: "bob", pos: pos{1, 2}, ttl: time.Second, tags: []string{"a"}}
+	got := consoleRecord(t, opts, func(l jlog.Logger) { l.(jlog.FieldLogger).Infow("obj", jlog.Object("user", e)) })
+	if want := "obj user={id=3 name=[REDACTED] pos={x=1 y=[REDACTED]} ttl=1s tags=[\"a\"] alive=true}"; got != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+	got = consoleRecord(t, opts, func(l jlog.Logger) { l.Info("arg ", e) })
+	if strings.Contains(got, "bob") {
+		t.Fatalf("argument not redacted, got %q", got)
+	}
+	got = consoleRecord(t, nil, func(l jlog.Logger) { l.Info(e) })
+	if !strings.Contains(got, "name=bob") {
+		t.Fatalf("redacted without LogRedactFields, got %q", got)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	opts := []jlog.Option{
+		jlog.LogScrub(regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`), ""),
+		jlog.LogScrub(regexp.MustCompile(`(\d{3})\d{4}(\d{4})`), "$1****$2"),
+	}
+	got := consoleRecord(t, opts, func(l jlog.Logger) {
+		l.Infof("mail %s phone %d", "bob@example.com", int64(13812345678))
+	})
+	if got != "mail [REDACTED] phone 138****5678" {
+		t.Fatalf("got %q", got)
+	}
+	got = consoleRecord(t, opts, func(l jlog.Logger) { l.Info("nothing to scrub") })
+	if got != "nothing to scrub" {
+		t.Fatalf("got %q", got)
+	}
+}
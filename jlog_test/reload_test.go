@@ -0,0 +1,209 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/jlogtest"
+)
+
+func writeConfig(t *testing.T, path string, mtime time.Time, format string, args ...interface{}) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(fmt.Sprintf(format, args...)), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	jlog.StdLogInit()
+	os.Stdout = stdout
+	defer jlog.StdLogInit()
+
+	dir := t.TempDir()
+	sinkA, sinkB := filepath.Join(dir, "a.sink"), filepath.Join(dir, "b.sink")
+	path := filepath.Join(dir, "jlog.json")
+	now := time.Now()
+	writeConfig(t, path, now, `{"dir": %q, "level": "INFO", "sinks": [{"level": "WARN", "path": %q}]}`, dir, sinkA)
+	cfg, err := jlog.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := jlog.NewFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop, err := l.WatchConfig(path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WatchConfig: %v", err)
+	}
+	defer stop()
+
+	l.Warn("dropped warn")
+	for i := 0; i < 1000; i++ {
+		l.Infof("queued %d", i)
+	}
+	writeConfig(t, path, now.Add(2*time.Second), `{"dir": %q, "level": "ERROR", "caller": true,
+		"prefixLevels": {"net": "INFO"}, "sinks": [{"level": "ERROR", "path": %q}]}`, dir, sinkB)
+
+	for deadline := time.Now().Add(5 * time.Second); !l.Enabled(jlog.WARN); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("config not reloaded")
+		}
+	}
+	l.Warn("live warn")
+	l.Output(jlog.WARN, "net", 0, "net warn")
+	l.Error("to sink b")
+	l.Close()
+	stop()
+
+	got := readLog(t, dir, "inf")
+	for i := 0; i < 1000; i++ {
+		if !strings.Contains(got, fmt.Sprintf("queued %d\n", i)) && !strings.Contains(got, fmt.Sprintf("queued %d [", i)) {
+			t.Fatalf("record %d queued before the reload was lost", i)
+		}
+	}
+	if strings.Contains(got, "dropped warn") || !strings.Contains(got, "live warn") || strings.Contains(got, "net warn") {
+		t.Fatalf("levels not reloaded, got %q", got)
+	}
+	if data, _ := ioutil.ReadFile(sinkA); strings.Contains(string(data), "to sink b") {
+		t.Fatalf("old sink still written: %q", data)
+	}
+	if data, _ := ioutil.ReadFile(sinkB); !strings.Contains(string(data), "to sink b") || strings.Contains(string(data), "live warn") {
+		t.Fatalf("new sink got %q", data)
+	}
+
+	jlog.CloseStdLog()
+	_ = w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range []string{
+		`level: "INFO" -> "ERROR"`,
+		`prefixLevels.net: "" -> "INFO"`,
+		`sinks: "WARN=` + sinkA + `" -> "ERROR=` + sinkB + `"`,
+		`caller: "false" -> "true" needs a restart`,
+	} {
+		if !strings.Contains(string(out), change) {
+			t.Fatalf("std log misses %q, got %q", change, out)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	var out strings.Builder
+	clock := jlogtest.NewClock(time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC))
+	l := jlog.NewLogger(jlog.LogLevel(jlog.ERROR), jlog.LogConsoleWriter(jlog.INFO, &out), jlog.LogSync(true),
+		jlog.LogClock(clock), jlog.LogSampling(2, 3))
+	for i := 1; i <= 10; i++ {
+		l.Infof("first %d", i)
+	}
+	clock.Add(time.Second)
+	l.Infof("next second")
+	l.Close()
+
+	var kept []string
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		msg := line[strings.Index(line, "]:")+2:]
+		if i := strings.Index(msg, " ["); i >= 0 {
+			msg = msg[:i] // the dev caller
+		}
+		kept = append(kept, msg)
+	}
+	if got := strings.Join(kept, ","); got != "first 1,first 2,first 5,first 8,next second" {
+		t.Fatalf("sampled %q", got)
+	}
+}
+
+// TestReloadKeeps reloads a config leaving out the level, the level stays
+// and the sink file it lists again is not reopened.
+func TestReloadKeeps(t *testing.T) {
+	dir := t.TempDir()
+	sinkA, sinkB := filepath.Join(dir, "a.sink"), filepath.Join(dir, "b.sink")
+	path := filepath.Join(dir, "jlog.json")
+	now := time.Now()
+	writeConfig(t, path, now, `{"dir": %q, "level": "ERROR", "sinks": [{"level": "WARN", "path": %q}]}`, dir, sinkA)
+	cfg, err := jlog.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := jlog.NewFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	stop, err := l.WatchConfig(path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WatchConfig: %v", err)
+	}
+	defer stop()
+
+	// a reopened sink would create the file again
+	if err = os.Remove(sinkA); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, path, now.Add(2*time.Second), `{"dir": %q, "sinks": [{"level": "WARN", "path": %q},
+		{"level": "ERROR", "path": %q}]}`, dir, sinkA, sinkB)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		l.Error("probe")
+		_ = l.Sync()
+		if _, err = os.Stat(sinkB); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("config not reloaded")
+		}
+	}
+	if !l.Enabled(jlog.WARN) {
+		t.Fatal("level reset by a config leaving it out")
+	}
+	if _, err = os.Stat(sinkA); !os.IsNotExist(err) {
+		t.Fatalf("unchanged sink reopened: %v", err)
+	}
+}
+
+// TestSamplingConcurrent logs from many goroutines in the same second, the
+// sampler keeps exactly the initial records.
+func TestSamplingConcurrent(t *testing.T) {
+	const goroutines, records, initial = 16, 1000, 100
+	var out strings.Builder
+	clock := jlogtest.NewClock(time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC))
+	l := jlog.NewLogger(jlog.LogLevel(jlog.ERROR), jlog.LogConsoleWriter(jlog.INFO, &out),
+		jlog.LogClock(clock), jlog.LogSampling(initial, 0))
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				l.Info("sampled")
+			}
+		}()
+	}
+	wg.Wait()
+	l.Close()
+
+	if got := strings.Count(out.String(), "\n"); got != initial {
+		t.Fatalf("kept %d records, want %d", got, initial)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+type color int
+
+func (c color) String() string { return [...]string{"red", "green"}[c] }
+
+type money int64
+
+func (m money) Format(f fmt.State, verb rune) {
+	_, _ = fmt.Fprintf(f, "$%d.%02d", int64(m)/100, int64(m)%100)
+}
+
+type level uint8
+
+type ipv4 [4]byte
+
+func (ip ipv4) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], ip[3])), nil
+}
+
+type point struct {
+	X, Y int
+}
+
+type account struct {
+	Name     string        `json:"name"`
+	Password string        `json:"password" jlog:"redact"`
+	Note     string        `json:"note,omitempty"`
+	Skip     int           `json:"-"`
+	TTL      time.Duration `json:"ttl"`
+	Color    color         `json:"color"`
+	Err      error         `json:"err"`
+	Any      interface{}   `json:"any"`
+	Next     *account      `json:"next"`
+	hidden   int
+}
+
+var renderCases = []struct {
+	name string
+	arg  interface{}
+}{
+	{"nil", nil},
+	{"bool", true},
+	{"int", -42},
+	{"int8", int8(-8)},
+	{"uint8", uint8(200)},
+	{"uint64", uint64(math.MaxUint64)},
+	{"float32", float32(0.1)},
+	{"float64", 3.25},
+	{"nan", math.NaN()},
+	{"inf", math.Inf(-1)},
+	{"complex", complex(1, -2)},
+	{"string", "a \"b\"\tc"},
+	{"bytes", []byte("raw")},
+	{"named int", level(3)},
+	{"pointer", &point{1, 2}},
+	{"nil pointer", (*point)(nil)},
+	{"pointer to int", func() *int { i := 5; return &i }()},
+	{"struct", point{1, 2}},
+	{"struct tags", account{Name: "bob", Password: "secret", Skip: 1, TTL: 90 * time.Second, Color: 1,
+		Err: errors.New("denied"), Any: []interface{}{nil, 1.5, "x"}, hidden: 1}},
+	{"nested struct", &account{Name: "a", Next: &account{Name: "b", Note: "n"}}},
+	{"map sorted", map[string]int{"b": 2, "c": 3, "a": 1}},
+	{"map int keys", map[int]string{10: "ten", 2: "two", -1: "minus"}},
+	{"map text keys", map[ipv4]bool{{10, 0, 0, 2}: true, {10, 0, 0, 1}: false}},
+	{"nil map", map[string]int(nil)},
+	{"slice", []int{1, 2, 3}},
+	{"nil slice", []string(nil)},
+	{"byte slice nested", []interface{}{[]byte("hi"), uint8(7)}},
+	{"array", [3]uint8{1, 2, 3}},
+	{"slice of pointers", []*point{{1, 2}, nil}},
+	{"escapes", []string{"<a&b>", "line\nbreak", "\x01", "\xff"}},
+	{"time", time.Date(2021, 6, 7, 8, 9, 10, 123456000, time.UTC)},
+	{"time in struct", struct{ At time.Time }{time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)}},
+	{"duration", 1500 * time.Millisecond},
+	{"duration in slice", []time.Duration{time.Second, 250 * time.Microsecond}},
+	{"error", errors.New("boom")},
+	{"wrapped error", fmt.Errorf("save: %w", fmt.Errorf("open %s: %w", "a.txt", os.ErrNotExist))},
+	{"errors in map", map[string]error{"a": errors.New("x"), "b": nil}},
+	{"stringer", color(0)},
+	{"stringers in slice", []color{0, 1}},
+	{"formatter", money(1234)},
+	{"formatter in map", map[string]money{"price": 99}},
+	{"marshaler", pos{1, 2}},
+	{"marshaler in slice", []pos{{3, 4}}},
+	{"json marshaler", struct{ Raw rawJSON }{rawJSON(`{"k":1}`)}},
+	{"json marshaler multi-line", struct{ Raw rawJSON }{rawJSON("{\n  \"k\": [1,\n 2]\n}")}},
+	{"json marshaler invalid", struct{ Raw rawJSON }{rawJSON("{\"k\":\n")}},
+	{"json marshaler panic", struct{ Raw panicJSON }{}},
+	{"text marshaler panic", []panicText{{}}},
+	{"text key panic", map[panicText]int{{}: 1}},
+	{"func", (func())(nil)},
+}
+
+// rawJSON is a json.Marshaler.
+type rawJSON string
+
+func (j rawJSON) MarshalJSON() ([]byte, error) { return []byte(j), nil }
+
+type panicJSON struct{}
+
+func (panicJSON) MarshalJSON() ([]byte, error) { panic("bad json") }
+
+type panicText struct{}
+
+func (panicText) MarshalText() ([]byte, error) { panic("bad text") }
+
+func TestRenderGolden(t *testing.T) {
+	var out strings.Builder
+	for _, c := range renderCases {
+		out.WriteString(c.name)
+		out.WriteString(": ")
+		out.WriteString(appendArg(c.arg))
+		out.WriteByte('\n')
+	}
+
+	golden := filepath.Join("testdata", "render.golden")
+	if *update {
+		if err := ioutil.WriteFile(golden, []byte(out.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v, run go test -update", err)
+	}
+
+	got := bufio.NewScanner(strings.NewReader(out.String()))
+	exp := bufio.NewScanner(strings.NewReader(string(want)))
+	for got.Scan() {
+		if !exp.Scan() {
+			t.Fatalf("extra line %q", got.Text())
+		}
+		if got.Text() != exp.Text() {
+			t.Errorf("got  %s\nwant %s", got.Text(), exp.Text())
+		}
+	}
+	if exp.Scan() {
+		t.Fatalf("missing line %q", exp.Text())
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+)
+
+func checkStdRecord(t *testing.T, got, msg string) {
+	t.Helper()
+	for _, line := range strings.Split(got, "\n") {
+		if !strings.Contains(line, msg) {
+			continue
+		}
+		if !strings.Contains(line, "]:"+msg) {
+			t.Fatalf("stdlib prefix kept: %q", line)
+		}
+		// dev builds append the caller, it must point at this file.
+		if strings.Contains(line, ".go:") && !strings.Contains(line, "std_log_test.go:") {
+			t.Fatalf("wrong caller: %q", line)
+		}
+		return
+	}
+	t.Fatalf("record %q not found in %q", msg, got)
+}
+
+func TestNewStdLog(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR))
+	std := jlog.NewStdLog(l, jlog.WARN)
+	std.Printf("third party %d", 42)
+	l.Close()
+
+	checkStdRecord(t, readLog(t, dir, "wrn"), "third party 42")
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	dir := t.TempDir()
+	jlog.SetDefault(jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR)))
+	restore := jlog.RedirectStdLog(jlog.INFO)
+	log.Println("from stdlib")
+	restore()
+	jlog.CloseGLog()
+
+	if log.Flags() != log.LstdFlags {
+		t.Fatalf("flags not restored: %d", log.Flags())
+	}
+	checkStdRecord(t, readLog(t, dir, "inf"), "from stdlib")
+}
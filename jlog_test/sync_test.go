@@ -0,0 +1,60 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+)
+
+func TestLogSync(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogSync(true))
+
+	l.Info("written before return")
+	if got := readLog(t, dir, "inf"); !strings.Contains(got, "written before return") {
+		t.Fatalf("record not written by the log call, got %q", got)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Warnf("worker %d record %d", i, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	got := readLog(t, dir, "inf")
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 50; j++ {
+			// dev builds append " [file:line]"
+			rec := fmt.Sprintf("worker %d record %d", i, j)
+			if !strings.Contains(got, rec+"\n") && !strings.Contains(got, rec+" [") {
+				t.Fatalf("missing worker %d record %d", i, j)
+			}
+		}
+	}
+	if wrn := readLog(t, dir, "wrn"); strings.Count(wrn, "\n") != 400 || strings.Contains(wrn, "before return") {
+		t.Fatalf("wrn file has %d lines", strings.Count(wrn, "\n"))
+	}
+
+	l.Close()
+	l.Close()
+	l.Error("after close")
+	if got := readLog(t, dir, "err"); strings.Contains(got, "after close") {
+		t.Fatalf("record written after close: %q", got)
+	}
+}
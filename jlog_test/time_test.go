@@ -0,0 +1,93 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+func headerTime(t *testing.T, opts ...jlog.Option) string {
+	t.Helper()
+	var out bytes.Buffer
+	opts = append(opts, jlog.LogLevel(jlog.ERROR), jlog.LogConsoleWriter(jlog.INFO, &out), jlog.LogSync(true))
+	l := jlog.NewLogger(opts...)
+	l.Info("msg")
+	l.Close()
+
+	got := out.String()
+	i := strings.Index(got, " [I]:msg")
+	if i < 0 {
+		t.Fatalf("unexpected record %q", got)
+	}
+	return got[:i]
+}
+
+func TestTimeFormat(t *testing.T) {
+	if got := headerTime(t); !regexp.MustCompile(`^\d{8} \d\d:\d\d:\d\d\.\d{6}$`).MatchString(got) {
+		t.Fatalf("default format got %q", got)
+	}
+
+	got := headerTime(t, jlog.LogTimeFormat(time.RFC3339Nano), jlog.LogTimeZone(time.UTC))
+	ts, err := time.Parse(time.RFC3339Nano, got)
+	if err != nil || !strings.HasSuffix(got, "Z") {
+		t.Fatalf("RFC3339Nano UTC got %q: %v", got, err)
+	}
+	if d := time.Since(ts); d < 0 || d > time.Minute {
+		t.Fatalf("RFC3339Nano time %v is off by %v", ts, d)
+	}
+
+	got = headerTime(t, jlog.LogTimeFormat(time.RFC3339), jlog.LogTimeZone(time.FixedZone("X", 5*3600+1800)))
+	if !strings.HasSuffix(got, "+05:30") {
+		t.Fatalf("RFC3339 zone got %q", got)
+	}
+
+	for format, unit := range map[string]time.Duration{
+		jlog.TimeFormatUnixMilli: time.Millisecond,
+		jlog.TimeFormatUnixNano:  time.Nanosecond,
+	} {
+		got = headerTime(t, jlog.LogTimeFormat(format))
+		n, err := strconv.ParseInt(got, 10, 64)
+		if err != nil {
+			t.Fatalf("%s got %q: %v", format, got, err)
+		}
+		if d := time.Since(time.Unix(0, n*int64(unit))); d < 0 || d > time.Minute {
+			t.Fatalf("%s time is off by %v", format, d)
+		}
+	}
+
+	if got = headerTime(t, jlog.LogTimeFormat("15h04"), jlog.LogTimeZone(time.UTC)); !regexp.MustCompile(`^\d\dh\d\d$`).MatchString(got) {
+		t.Fatalf("custom layout got %q", got)
+	}
+}
+
+func TestTimeZoneFileName(t *testing.T) {
+	dir := t.TempDir()
+	loc := time.FixedZone("Far", 14*3600)
+	name := func() string {
+		return jlog.WithoutExt(filepath.Base(os.Args[0])) + "." + time.Now().In(loc).Format("2006010215") + ".0.inf.log"
+	}
+	before := name()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogTimeZone(loc))
+	l.Info("zoned")
+	l.Close()
+
+	// the hour may turn while logging.
+	_, err := os.Stat(filepath.Join(dir, before))
+	if err != nil {
+		_, err = os.Stat(filepath.Join(dir, name()))
+	}
+	if err != nil {
+		t.Fatalf("rotated file not named in the zone: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog_test
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+)
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR))
+	w := jlog.Writer(l, jlog.WARN, "proc")
+	for _, chunk := range []string{"hel", "lo\nwor", "ld\r\n\n", "tail"} {
+		if _, err := io.WriteString(w, chunk); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := w.Write([]byte("late\n")); err == nil {
+		t.Fatalf("Write after Close succeeded")
+	}
+	l.Close()
+
+	want := []string{"[proc]hello ", "[proc]world ", "[proc]tail "}
+	if got := writerBodies(t, dir); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriterSplit(t *testing.T) {
+	const maxLineSize = 1 << 16
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR))
+	w := jlog.Writer(l, jlog.WARN, "proc")
+	for _, chunk := range []string{"\n\n", strings.Repeat("x", 2*maxLineSize+10), "\n\r\n"} {
+		if _, err := io.WriteString(w, chunk); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	_ = w.Close()
+	l.Close()
+
+	var sizes []int
+	for _, body := range writerBodies(t, dir) {
+		sizes = append(sizes, len(body)-len("[proc] "))
+	}
+	if want := []int{maxLineSize, maxLineSize, 10}; !reflect.DeepEqual(sizes, want) {
+		t.Fatalf("got lines of %v bytes, want %v", sizes, want)
+	}
+}
+
+// writerBodies returns the records of the wrn file of dir past their header.
+func writerBodies(t *testing.T, dir string) []string {
+	t.Helper()
+	var bodies []string
+	for _, line := range strings.Split(strings.TrimSuffix(readLog(t, dir, "wrn"), "\n"), "\n") {
+		body := line[strings.Index(line, "]:")+2:]
+		if i := strings.Index(body, "  ["); i >= 0 {
+			body = body[:i+1] // dev builds append the caller.
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies
+}
+
+// plainLogger is a Logger outside this package, with none of the optional
+// interfaces.
+type plainLogger struct{ lines []string }
+
+func (p *plainLogger) Debug(args ...interface{}) { p.Debugf("%s", fmt.Sprint(args...)) }
+func (p *plainLogger) Info(args ...interface{})  { p.Infof("%s", fmt.Sprint(args...)) }
+func (p *plainLogger) Warn(args ...interface{})  { p.Warnf("%s", fmt.Sprint(args...)) }
+func (p *plainLogger) Error(args ...interface{}) { p.Errorf("%s", fmt.Sprint(args...)) }
+func (p *plainLogger) Debugf(format string, args ...interface{}) {
+	p.lines = append(p.lines, "D "+fmt.Sprintf(format, args...))
+}
+func (p *plainLogger) Infof(format string, args ...interface{}) {
+	p.lines = append(p.lines, "I "+fmt.Sprintf(format, args...))
+}
+func (p *plainLogger) Warnf(format string, args ...interface{}) {
+	p.lines = append(p.lines, "W "+fmt.Sprintf(format, args...))
+}
+func (p *plainLogger) Errorf(format string, args ...interface{}) {
+	p.lines = append(p.lines, "E "+fmt.Sprintf(format, args...))
+}
+
+func TestWriterPlainLogger(t *testing.T) {
+	var lg jlog.Logger = &plainLogger{}
+	if _, ok := lg.(jlog.Syncer); ok {
+		t.Fatal("plainLogger is a Syncer")
+	}
+	w := jlog.Writer(lg, jlog.WARN, "proc")
+	_, _ = io.WriteString(w, "one\ntwo")
+	_ = w.Close()
+	if got, want := lg.(*plainLogger).lines, []string{"W [proc]one", "W [proc]two"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
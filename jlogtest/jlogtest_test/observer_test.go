@@ -0,0 +1,76 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlogtest_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/jlogtest"
+)
+
+func TestObserver(t *testing.T) {
+	obs, logs := jlogtest.NewObserver()
+	var lg jlog.Logger = obs
+	lg.Info("player", 7, "joined")
+	lg.Errorf("load %s: %v", "map", errors.New("not found"))
+	obs.WithPrefix("room").Warn("full")
+
+	if logs.Len() != 3 {
+		t.Fatalf("got %d entries, want 3", logs.Len())
+	}
+	info := logs.FilterLevel(jlog.INFO).All()
+	if len(info) != 1 || info[0].Message != "player 7 joined" || len(info[0].Args) != 3 {
+		t.Fatalf("unexpected info entries %+v", info)
+	}
+	if info[0].File != "observer_test.go" || info[0].Line == 0 {
+		t.Fatalf("wrong caller %s:%d", info[0].File, info[0].Line)
+	}
+	if got := logs.FilterMessageContains("not found").Len(); got != 1 {
+		t.Fatalf("FilterMessageContains found %d entries", got)
+	}
+	if got := logs.FilterPrefix("room").FilterLevel(jlog.WARN).Len(); got != 1 {
+		t.Fatalf("FilterPrefix found %d entries", got)
+	}
+
+	if got := logs.TakeAll(); len(got) != 3 {
+		t.Fatalf("TakeAll returned %d entries", len(got))
+	}
+	if logs.Len() != 0 {
+		t.Fatalf("TakeAll left %d entries", logs.Len())
+	}
+}
+
+// recordTB is a testing.TB keeping what is logged to it, the other methods
+// are not called.
+type recordTB struct {
+	testing.TB
+	lines []string
+}
+
+func (r *recordTB) Helper()                 {}
+func (r *recordTB) Log(args ...interface{}) { r.lines = append(r.lines, fmt.Sprint(args...)) }
+func (r *recordTB) Logf(format string, args ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func TestLogger(t *testing.T) {
+	tb := &recordTB{}
+	lg := jlogtest.NewLoggerPrefix(tb, "test")
+	lg.Infof("shown with %s", "t.Log")
+	lg.Debug("debug", 1)
+	jlogtest.NewLogger(tb).Error("no prefix")
+	if err := lg.(jlog.Syncer).Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"[I]:[test]shown with t.Log", "[D]:[test]debug 1", "[E]:no prefix"}
+	if !reflect.DeepEqual(tb.lines, want) {
+		t.Fatalf("logged %q, want %q", tb.lines, want)
+	}
+}
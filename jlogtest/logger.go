@@ -0,0 +1,75 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlogtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tiger-game/jlog"
+)
+
+// tbLogger writes every record to t.Log so it shows up next to the test
+// output, attributed to the line that logged it.
+type tbLogger struct {
+	t      testing.TB
+	prefix string
+}
+
+// NewLogger returns a jlog.Logger writing all levels to t.Log.
+func NewLogger(t testing.TB) jlog.Logger { return &tbLogger{t: t} }
+
+// NewLoggerPrefix is NewLogger with the records prefixed by prefix.
+func NewLoggerPrefix(t testing.TB, prefix string) jlog.Logger {
+	return &tbLogger{t: t, prefix: prefix}
+}
+
+func (l *tbLogger) Debug(args ...interface{}) { l.t.Helper(); l.output(jlog.DEBUG, sprint(args)) }
+func (l *tbLogger) Info(args ...interface{})  { l.t.Helper(); l.output(jlog.INFO, sprint(args)) }
+func (l *tbLogger) Warn(args ...interface{})  { l.t.Helper(); l.output(jlog.WARN, sprint(args)) }
+func (l *tbLogger) Error(args ...interface{}) { l.t.Helper(); l.output(jlog.ERROR, sprint(args)) }
+func (l *tbLogger) Debugf(format string, args ...interface{}) {
+	l.t.Helper()
+	l.output(jlog.DEBUG, fmt.Sprintf(format, args...))
+}
+func (l *tbLogger) Infof(format string, args ...interface{}) {
+	l.t.Helper()
+	l.output(jlog.INFO, fmt.Sprintf(format, args...))
+}
+func (l *tbLogger) Warnf(format string, args ...interface{}) {
+	l.t.Helper()
+	l.output(jlog.WARN, fmt.Sprintf(format, args...))
+}
+func (l *tbLogger) Errorf(format string, args ...interface{}) {
+	l.t.Helper()
+	l.output(jlog.ERROR, fmt.Sprintf(format, args...))
+}
+func (l *tbLogger) Debugw(msg string, fields ...jlog.Field) {
+	l.t.Helper()
+	l.output(jlog.DEBUG, sprintw(msg, fields))
+}
+func (l *tbLogger) Infow(msg string, fields ...jlog.Field) {
+	l.t.Helper()
+	l.output(jlog.INFO, sprintw(msg, fields))
+}
+func (l *tbLogger) Warnw(msg string, fields ...jlog.Field) {
+	l.t.Helper()
+	l.output(jlog.WARN, sprintw(msg, fields))
+}
+func (l *tbLogger) Errorw(msg string, fields ...jlog.Field) {
+	l.t.Helper()
+	l.output(jlog.ERROR, sprintw(msg, fields))
+}
+func (l *tbLogger) Sync() error               { return nil }
+func (l *tbLogger) Enabled(_ jlog.Level) bool { return true }
+
+func (l *tbLogger) output(lv jlog.Level, msg string) {
+	l.t.Helper()
+	if l.prefix != "" {
+		l.t.Logf("[%c]:[%s]%s", jlog.LevelFlags[lv], l.prefix, msg)
+		return
+	}
+	l.t.Logf("[%c]:%s", jlog.LevelFlags[lv], msg)
+}
@@ -0,0 +1,196 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jlogtest provides jlog.Logger implementations for unit tests:
+// an observer keeping records in memory and a logger writing to testing.TB.
+package jlogtest
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+// Entry is a record captured by an Observer.
+type Entry struct {
+	Time    time.Time
+	Level   jlog.Level
+	Prefix  string
+	Message string
+	Args    []interface{} // the arguments of Info, Infof, ...
+	Fields  []jlog.Field  // the fields of Infow, ...
+	File    string
+	Line    int
+}
+
+// ObservedLogs is a concurrency safe list of captured entries.
+type ObservedLogs struct {
+	mu   sync.RWMutex
+	logs []Entry
+}
+
+// Len returns the number of entries.
+func (o *ObservedLogs) Len() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.logs)
+}
+
+// All returns a copy of the entries.
+func (o *ObservedLogs) All() []Entry {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	ret := make([]Entry, len(o.logs))
+	copy(ret, o.logs)
+	return ret
+}
+
+// TakeAll returns the entries and clears the list.
+func (o *ObservedLogs) TakeAll() []Entry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ret := o.logs
+	o.logs = nil
+	return ret
+}
+
+// Filter returns a new ObservedLogs with the entries matching keep.
+func (o *ObservedLogs) Filter(keep func(e Entry) bool) *ObservedLogs {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	filtered := &ObservedLogs{}
+	for _, e := range o.logs {
+		if keep(e) {
+			filtered.logs = append(filtered.logs, e)
+		}
+	}
+	return filtered
+}
+
+// FilterLevel returns the entries logged at lv.
+func (o *ObservedLogs) FilterLevel(lv jlog.Level) *ObservedLogs {
+	return o.Filter(func(e Entry) bool { return e.Level == lv })
+}
+
+// FilterPrefix returns the entries logged with prefix.
+func (o *ObservedLogs) FilterPrefix(prefix string) *ObservedLogs {
+	return o.Filter(func(e Entry) bool { return e.Prefix == prefix })
+}
+
+// FilterField returns the entries having a field named key.
+func (o *ObservedLogs) FilterField(key string) *ObservedLogs {
+	return o.Filter(func(e Entry) bool {
+		for _, f := range e.Fields {
+			if f.Key == key {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FilterMessageContains returns the entries whose message contains sub.
+func (o *ObservedLogs) FilterMessageContains(sub string) *ObservedLogs {
+	return o.Filter(func(e Entry) bool { return strings.Contains(e.Message, sub) })
+}
+
+func (o *ObservedLogs) add(e Entry) {
+	o.mu.Lock()
+	o.logs = append(o.logs, e)
+	o.mu.Unlock()
+}
+
+// Observer is a jlog.Logger recording every record into its ObservedLogs.
+type Observer struct {
+	logs   *ObservedLogs
+	prefix string
+}
+
+// NewObserver returns an Observer and the logs it records to.
+func NewObserver() (*Observer, *ObservedLogs) {
+	logs := &ObservedLogs{}
+	return &Observer{logs: logs}, logs
+}
+
+// WithPrefix returns an Observer sharing the logs that records with prefix,
+// like jlog.NewLogByPrefix.
+func (o *Observer) WithPrefix(prefix string) *Observer {
+	return &Observer{logs: o.logs, prefix: prefix}
+}
+
+func (o *Observer) Debug(args ...interface{}) { o.output(jlog.DEBUG, args) }
+func (o *Observer) Info(args ...interface{})  { o.output(jlog.INFO, args) }
+func (o *Observer) Warn(args ...interface{})  { o.output(jlog.WARN, args) }
+func (o *Observer) Error(args ...interface{}) { o.output(jlog.ERROR, args) }
+func (o *Observer) Debugf(format string, args ...interface{}) {
+	o.outputf(jlog.DEBUG, format, args)
+}
+func (o *Observer) Infof(format string, args ...interface{}) {
+	o.outputf(jlog.INFO, format, args)
+}
+func (o *Observer) Warnf(format string, args ...interface{}) {
+	o.outputf(jlog.WARN, format, args)
+}
+func (o *Observer) Errorf(format string, args ...interface{}) {
+	o.outputf(jlog.ERROR, format, args)
+}
+func (o *Observer) Debugw(msg string, fields ...jlog.Field) { o.outputw(jlog.DEBUG, msg, fields) }
+func (o *Observer) Infow(msg string, fields ...jlog.Field)  { o.outputw(jlog.INFO, msg, fields) }
+func (o *Observer) Warnw(msg string, fields ...jlog.Field)  { o.outputw(jlog.WARN, msg, fields) }
+func (o *Observer) Errorw(msg string, fields ...jlog.Field) { o.outputw(jlog.ERROR, msg, fields) }
+func (o *Observer) Sync() error                             { return nil }
+func (o *Observer) Enabled(_ jlog.Level) bool               { return true }
+
+func (o *Observer) output(lv jlog.Level, args []interface{}) {
+	o.add(lv, sprint(args), args, nil)
+}
+
+func (o *Observer) outputf(lv jlog.Level, format string, args []interface{}) {
+	o.add(lv, fmt.Sprintf(format, args...), args, nil)
+}
+
+func (o *Observer) outputw(lv jlog.Level, msg string, fields []jlog.Field) {
+	o.add(lv, msg, nil, append([]jlog.Field(nil), fields...))
+}
+
+func (o *Observer) add(lv jlog.Level, msg string, args []interface{}, fields []jlog.Field) {
+	e := Entry{
+		Time:    time.Now(),
+		Level:   lv,
+		Prefix:  o.prefix,
+		Message: msg,
+		Args:    args,
+		Fields:  fields,
+	}
+	// skip add, output and the Logger method.
+	if _, file, line, ok := runtime.Caller(3); ok {
+		e.File, e.Line = filepath.Base(file), line
+	}
+	o.logs.add(e)
+}
+
+// sprint renders args the way jlog's Info does, without the trailing space.
+func sprint(args []interface{}) string {
+	buf := &jlog.Buffer{}
+	for i, arg := range args {
+		if i > 0 {
+			_ = buf.WriteByte(' ')
+		}
+		jlog.DebugBufferAppend(buf, arg)
+	}
+	return buf.String()
+}
+
+// sprintw renders msg and fields the way jlog's Infow does.
+func sprintw(msg string, fields []jlog.Field) string {
+	buf := &jlog.Buffer{}
+	_, _ = buf.WriteString(msg)
+	jlog.DebugFieldsAppend(buf, fields...)
+	return buf.String()
+}
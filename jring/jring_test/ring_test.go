@@ -0,0 +1,162 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jring_test
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog/jring"
+)
+
+func TestMPSC(t *testing.T) {
+	q := jring.New(3)
+	if q.Cap() != 4 {
+		t.Fatalf("Cap = %d, want 4", q.Cap())
+	}
+	for i := 0; i < 4; i++ {
+		if !q.Push(i) {
+			t.Fatalf("Push(%d) failed", i)
+		}
+	}
+	if q.Push(4) {
+		t.Fatal("Push on a full queue succeeded")
+	}
+	if v, ok := q.Pop(); !ok || v.(int) != 0 {
+		t.Fatalf("Pop = %v, %v", v, ok)
+	}
+	batch := q.PopBatch(make([]interface{}, 0, 8))
+	if len(batch) != 3 || batch[0].(int) != 1 || batch[2].(int) != 3 {
+		t.Fatalf("PopBatch = %v", batch)
+	}
+	if _, ok := q.Pop(); ok {
+		t.Fatal("Pop on an empty queue succeeded")
+	}
+}
+
+func TestMPSCProducers(t *testing.T) {
+	const producers, n = 8, 10000
+	q := jring.New(64)
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				for !q.Push([2]int{p, i}) {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+
+	next := make([]int, producers)
+	batch := make([]interface{}, 0, 16)
+	for got := 0; got < producers*n; {
+		batch = q.PopBatch(batch[:0])
+		if len(batch) == 0 {
+			runtime.Gosched()
+			continue
+		}
+		for _, v := range batch {
+			e := v.([2]int)
+			if e[1] != next[e[0]] {
+				t.Fatalf("producer %d: got %d, want %d", e[0], e[1], next[e[0]])
+			}
+			next[e[0]]++
+		}
+		got += len(batch)
+	}
+	wg.Wait()
+}
+
+type queue interface {
+	push(v interface{})
+	drain(batch []interface{}) []interface{}
+}
+
+type ringQueue struct{ q *jring.MPSC }
+
+func (r ringQueue) push(v interface{}) {
+	for !r.q.Push(v) {
+		runtime.Gosched()
+	}
+}
+
+func (r ringQueue) drain(batch []interface{}) []interface{} {
+	return r.q.PopBatch(batch)
+}
+
+type chanQueue chan interface{}
+
+func (c chanQueue) push(v interface{}) { c <- v }
+
+func (c chanQueue) drain(batch []interface{}) []interface{} {
+	batch = append(batch, <-c)
+	for len(batch) < cap(batch) {
+		select {
+		case v := <-c:
+			batch = append(batch, v)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// benchQueue pushes b.N timestamps from producers goroutines, reports the
+// throughput as ns/op and the enqueue to dequeue latency percentiles.
+func benchQueue(b *testing.B, q queue, producers int) {
+	lat := make([]time.Duration, 0, b.N)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		batch := make([]interface{}, 0, 256)
+		for len(lat) < b.N {
+			batch = q.drain(batch[:0])
+			if len(batch) == 0 {
+				runtime.Gosched()
+				continue
+			}
+			now := time.Now()
+			for _, v := range batch {
+				lat = append(lat, now.Sub(v.(time.Time)))
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		n := b.N / producers
+		if p < b.N%producers {
+			n++
+		}
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				q.push(time.Now())
+			}
+		}(n)
+	}
+	wg.Wait()
+	<-done
+	b.StopTimer()
+
+	sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+	b.ReportMetric(float64(lat[len(lat)/2]), "p50-ns")
+	b.ReportMetric(float64(lat[len(lat)*99/100]), "p99-ns")
+}
+
+func Benchmark_Ring1(b *testing.B)  { benchQueue(b, ringQueue{jring.New(1 << 10)}, 1) }
+func Benchmark_Ring8(b *testing.B)  { benchQueue(b, ringQueue{jring.New(1 << 10)}, 8) }
+func Benchmark_Ring64(b *testing.B) { benchQueue(b, ringQueue{jring.New(1 << 10)}, 64) }
+func Benchmark_Chan1(b *testing.B)  { benchQueue(b, make(chanQueue, 1<<10), 1) }
+func Benchmark_Chan8(b *testing.B)  { benchQueue(b, make(chanQueue, 1<<10), 8) }
+func Benchmark_Chan64(b *testing.B) { benchQueue(b, make(chanQueue, 1<<10), 64) }
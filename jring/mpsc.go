@@ -0,0 +1,95 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jring provides a bounded lock-free multi-producer single-consumer
+// queue, refer to: Dmitry Vyukov's bounded MPMC queue.
+package jring
+
+import (
+	"sync/atomic"
+)
+
+const cacheLine = 64
+
+type cell struct {
+	seq uint64 // pos when free, pos+1 once the value for pos is published
+	val interface{}
+}
+
+// MPSC is a bounded FIFO queue. Push may be called from any goroutine,
+// Pop, PopBatch and Len only from the single consumer goroutine.
+type MPSC struct {
+	head  uint64 // next position to push, shared by the producers
+	_     [cacheLine - 8]byte
+	tail  uint64 // next position to pop, owned by the consumer
+	_     [cacheLine - 8]byte
+	mask  uint64
+	cells []cell
+}
+
+// New returns a queue holding at least size values, size is rounded up
+// to a power of two.
+func New(size int) *MPSC {
+	n := 2
+	for n < size {
+		n <<= 1
+	}
+	q := &MPSC{mask: uint64(n - 1), cells: make([]cell, n)}
+	for i := range q.cells {
+		q.cells[i].seq = uint64(i)
+	}
+	return q
+}
+
+// Cap returns the number of values the queue can hold.
+func (q *MPSC) Cap() int { return len(q.cells) }
+
+// Push appends v, it reports false without blocking when the queue is full.
+func (q *MPSC) Push(v interface{}) bool {
+	for {
+		pos := atomic.LoadUint64(&q.head)
+		c := &q.cells[pos&q.mask]
+		switch dif := int64(atomic.LoadUint64(&c.seq) - pos); {
+		case dif == 0:
+			if atomic.CompareAndSwapUint64(&q.head, pos, pos+1) {
+				c.val = v
+				atomic.StoreUint64(&c.seq, pos+1)
+				return true
+			}
+		case dif < 0:
+			return false
+		}
+		// another producer took pos, retry with the new head.
+	}
+}
+
+// Pop removes the oldest value, it reports false when the queue is empty or
+// the oldest slot is claimed by a producer that has not published yet.
+func (q *MPSC) Pop() (interface{}, bool) {
+	c := &q.cells[q.tail&q.mask]
+	if atomic.LoadUint64(&c.seq) != q.tail+1 {
+		return nil, false
+	}
+	v := c.val
+	c.val = nil
+	atomic.StoreUint64(&c.seq, q.tail+q.mask+1)
+	q.tail++
+	return v, true
+}
+
+// PopBatch appends up to cap(dst)-len(dst) values to dst, oldest first.
+func (q *MPSC) PopBatch(dst []interface{}) []interface{} {
+	for len(dst) < cap(dst) {
+		v, ok := q.Pop()
+		if !ok {
+			break
+		}
+		dst = append(dst, v)
+	}
+	return dst
+}
+
+// Len returns the number of claimed slots, including those whose
+// producer has not published yet.
+func (q *MPSC) Len() int { return int(atomic.LoadUint64(&q.head) - q.tail) }
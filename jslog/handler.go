@@ -0,0 +1,168 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+// +build go1.21
+
+// Package jslog provides a log/slog Handler that writes through a jlog
+// logger, so slog based code shares jlog's files and rotation.
+package jslog
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+// Handler renders slog records as "msg key=value ..." bodies and queues
+// them to a jlog logger, which adds the usual header and caller.
+type Handler struct {
+	lg     jlog.Logger
+	opts   slog.HandlerOptions
+	pool   jlog.Pool
+	attrs  []byte   // pre-rendered attributes from WithAttrs
+	groups []string // open groups from WithGroup
+	prefix string   // groups joined with '.', used as key prefix
+}
+
+// NewHandler returns a Handler writing to lg. opts may be nil,
+// AddSource, Level and ReplaceAttr are honored.
+func NewHandler(lg jlog.Logger, opts *slog.HandlerOptions) *Handler {
+	h := &Handler{lg: lg, pool: jlog.NewPool()}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// Level maps a slog level to the closest jlog Level.
+func Level(lv slog.Level) jlog.Level {
+	switch {
+	case lv < slog.LevelInfo:
+		return jlog.DEBUG
+	case lv < slog.LevelWarn:
+		return jlog.INFO
+	case lv < slog.LevelError:
+		return jlog.WARN
+	default:
+		return jlog.ERROR
+	}
+}
+
+func (h *Handler) Enabled(_ context.Context, lv slog.Level) bool {
+	min := slog.LevelInfo
+	if h.opts.Level != nil {
+		min = h.opts.Level.Level()
+	}
+	if lv < min {
+		return false
+	}
+	e, ok := h.lg.(jlog.LevelEnabler)
+	return !ok || e.Enabled(Level(lv))
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	var (
+		file string
+		line int
+	)
+	if h.opts.AddSource && r.PC != 0 {
+		fs := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := fs.Next()
+		file, line = filepath.Base(f.File), f.Line
+	}
+
+	buf := h.pool.Get()
+	_, _ = buf.WriteString(r.Message)
+	_, _ = buf.Write(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		h.appendAttr(buf, h.prefix, h.groups, a)
+		return true
+	})
+	jlog.OutputBody(h.lg, Level(r.Level), file, line, buf)
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	buf := h.pool.Get()
+	_, _ = buf.Write(h.attrs)
+	for _, a := range attrs {
+		h.appendAttr(buf, h.prefix, h.groups, a)
+	}
+	h2.attrs = append([]byte(nil), buf.Bytes()...)
+	buf.Free()
+	return &h2
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// appendAttr writes " key=value", groups are flattened into dotted keys.
+// The values of the keys redacted by the logger, bare or dotted, are masked.
+func (h *Handler) appendAttr(buf *jlog.Buffer, prefix string, groups []string, a slog.Attr) {
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.opts.ReplaceAttr(groups, a)
+	}
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			h.appendAttr(buf, prefix, groups, ga)
+		}
+		return
+	}
+
+	_ = buf.WriteByte(' ')
+	_, _ = buf.WriteString(prefix)
+	_, _ = buf.WriteString(a.Key)
+	_ = buf.WriteByte('=')
+	if jlog.RedactedKey(h.lg, a.Key) || (prefix != "" && jlog.RedactedKey(h.lg, prefix+a.Key)) {
+		_, _ = buf.WriteString(jlog.Redacted)
+		return
+	}
+	appendValue(buf, a.Value)
+}
+
+func appendValue(buf *jlog.Buffer, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		jlog.AppendFieldString(buf, v.String())
+	case slog.KindInt64:
+		buf.AppendInt(v.Int64())
+	case slog.KindUint64:
+		buf.AppendUint(v.Uint64())
+	case slog.KindFloat64:
+		buf.AppendFloat(v.Float64(), 64)
+	case slog.KindBool:
+		buf.AppendBool(v.Bool())
+	case slog.KindDuration:
+		_, _ = buf.WriteString(v.Duration().String())
+	case slog.KindTime:
+		_, _ = buf.WriteString(v.Time().Format(time.RFC3339Nano))
+	default:
+		jlog.DebugBufferAppend(buf, v.Any())
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+// +build go1.21
+
+package jslog_test
+
+import (
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/jslog"
+)
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR))
+	h := jslog.NewHandler(jlog.NewLogByPrefixLevel("slog", jlog.ERROR), nil)
+	// NewLogByPrefixLevel follows the default logger.
+	jlog.SetDefault(l)
+	defer jlog.CloseGLog()
+
+	log := slog.New(h).With("server", "s1").WithGroup("req")
+	log.Warn("slow request", "path", "/a b", slog.Duration("took", 1500*time.Millisecond),
+		slog.Group("user", "id", 7))
+	log.Debug("dropped")
+	if err := jlog.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	name := jlog.WithoutExt(filepath.Base(os.Args[0])) + ".wrn"
+	data, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	want := `[W]:[slog]slow request server=s1 req.path="/a b" req.took=1.5s req.user.id=7`
+	if !strings.Contains(string(data), want) {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+	if strings.Contains(string(data), "dropped") {
+		t.Fatalf("debug record written: %q", data)
+	}
+}
+
+func TestHandlerRedact(t *testing.T) {
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogRedactFields("email", "req.token"))
+	log := slog.New(jslog.NewHandler(l, nil)).With("email", "a@b.c")
+	log.Info("login", "user", 7, slog.Group("req", "token", "t0k", "email", "c@d.e", "path", "/"))
+	l.Close()
+
+	name := jlog.WithoutExt(filepath.Base(os.Args[0])) + ".inf"
+	data, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	want := `login email=[REDACTED] user=7 req.token=[REDACTED] req.email=[REDACTED] req.path=/`
+	if !strings.Contains(string(data), want) {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	cases := map[slog.Level]jlog.Level{
+		slog.LevelDebug:     jlog.DEBUG,
+		slog.LevelInfo:      jlog.INFO,
+		slog.LevelInfo + 2:  jlog.INFO,
+		slog.LevelWarn:      jlog.WARN,
+		slog.LevelError:     jlog.ERROR,
+		slog.LevelError + 4: jlog.ERROR,
+	}
+	for in, want := range cases {
+		if got := jslog.Level(in); got != want {
+			t.Errorf("Level(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
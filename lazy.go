@@ -0,0 +1,41 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Lazy defers building an expensive argument until the record is written,
+// a record dropped by its level never calls it:
+//
+//	l.Debug("state:", jlog.Lazy(func() interface{} { return dump() }))
+type Lazy func() interface{}
+
+// Format lets Lazy be passed to the f variants, the verb applies to the
+// returned value.
+func (f Lazy) Format(s fmt.State, verb rune) {
+	_, _ = fmt.Fprintf(s, formatDirective(s, verb), f())
+}
+
+// formatDirective rebuilds the directive, such as "%-08.3f", that produced s.
+func formatDirective(s fmt.State, verb rune) string {
+	b := make([]byte, 1, 16)
+	b[0] = '%'
+	for _, c := range "+-# 0" {
+		if s.Flag(int(c)) {
+			b = append(b, byte(c))
+		}
+	}
+	if w, ok := s.Width(); ok {
+		b = strconv.AppendInt(b, int64(w), 10)
+	}
+	if p, ok := s.Precision(); ok {
+		b = append(b, '.')
+		b = strconv.AppendInt(b, int64(p), 10)
+	}
+	return string(b) + string(verb)
+}
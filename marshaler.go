@@ -0,0 +1,169 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// LogMarshaler is implemented by the types choosing how they appear in the
+// logs, it is preferred to fmt.Stringer and to the reflection fallback.
+// The pairs are rendered as {key=value ...}, the strings quoted like the
+// typed fields.
+type LogMarshaler interface {
+	MarshalLog(enc ObjectEncoder)
+}
+
+// ObjectEncoder receives the key/value pairs of a LogMarshaler.
+type ObjectEncoder interface {
+	AddBool(key string, val bool)
+	AddInt64(key string, val int64)
+	AddUint64(key string, val uint64)
+	AddFloat64(key string, val float64)
+	AddString(key, val string)
+	AddDuration(key string, val time.Duration)
+	AddTime(key string, val time.Time)
+	AddObject(key string, val LogMarshaler)
+	// AddAny renders val like an Info argument.
+	AddAny(key string, val interface{})
+}
+
+// bufferEncoder is the ObjectEncoder of a Buffer, kept in it so handing it
+// to MarshalLog does not allocate.
+type bufferEncoder struct {
+	buf    *Buffer
+	n      int                 // pairs written in the current object
+	redact map[string]struct{} // LogRedactFields of the logger rendering buf
+}
+
+// _Key writes the key of a pair, reporting false when it is redacted and
+// Redacted stands for the value.
+func (e *bufferEncoder) _Key(key string) bool {
+	if e.n > 0 {
+		_ = e.buf.WriteByte(' ')
+	}
+	e.n++
+	_, _ = e.buf.WriteString(key)
+	_ = e.buf.WriteByte('=')
+	if _, ok := e.redact[key]; ok {
+		_, _ = e.buf.WriteString(Redacted)
+		return false
+	}
+	return true
+}
+
+func (e *bufferEncoder) AddBool(key string, val bool) {
+	if !e._Key(key) {
+		return
+	}
+	e.buf.AppendBool(val)
+}
+
+func (e *bufferEncoder) AddInt64(key string, val int64) {
+	if !e._Key(key) {
+		return
+	}
+	e.buf.AppendInt(val)
+}
+
+func (e *bufferEncoder) AddUint64(key string, val uint64) {
+	if !e._Key(key) {
+		return
+	}
+	e.buf.AppendUint(val)
+}
+
+func (e *bufferEncoder) AddFloat64(key string, val float64) {
+	if !e._Key(key) {
+		return
+	}
+	e.buf.AppendFloat(val, 64)
+}
+
+func (e *bufferEncoder) AddString(key, val string) {
+	if !e._Key(key) {
+		return
+	}
+	appendString2Buffer(e.buf, val)
+}
+
+func (e *bufferEncoder) AddDuration(key string, val time.Duration) {
+	if !e._Key(key) {
+		return
+	}
+	appendDuration(e.buf, val)
+}
+
+func (e *bufferEncoder) AddTime(key string, val time.Time) {
+	if !e._Key(key) {
+		return
+	}
+	e.buf.AppendTime(val, time.RFC3339Nano)
+}
+
+func (e *bufferEncoder) AddObject(key string, val LogMarshaler) {
+	if !e._Key(key) {
+		return
+	}
+	appendMarshaler(e.buf, val)
+}
+
+func (e *bufferEncoder) AddAny(key string, val interface{}) {
+	if !e._Key(key) {
+		return
+	}
+	if val == nil {
+		_, _ = e.buf.WriteString("<nil>")
+		return
+	}
+	appendArg2Buffer(e.buf, val)
+}
+
+// appendMarshaler writes {key=value ...} of m, nested objects included.
+func appendMarshaler(buf *Buffer, m LogMarshaler) {
+	if isNilPointer(m) {
+		_, _ = buf.WriteString("<nil>")
+		return
+	}
+	defer recoverPanic(buf, "MarshalLog")
+
+	// the encoder is shared by the nested objects of buf.
+	n := buf.enc.n
+	buf.enc.buf, buf.enc.n = buf, 0
+	_ = buf.WriteByte('{')
+	m.MarshalLog(&buf.enc)
+	_ = buf.WriteByte('}')
+	buf.enc.n = n
+}
+
+func appendStringer(buf *Buffer, s fmt.Stringer) {
+	if isNilPointer(s) {
+		_, _ = buf.WriteString("<nil>")
+		return
+	}
+	defer recoverPanic(buf, "String")
+	_, _ = buf.WriteString(s.String())
+}
+
+// recoverPanic writes a panic of the method the way fmt does.
+func recoverPanic(buf *Buffer, method string) {
+	if r := recover(); r != nil {
+		_, _ = fmt.Fprintf(buf, "%%!v(PANIC=%s method: %v)", method, r)
+	}
+}
+
+// isNilPointer reports whether v is a nil pointer, whose methods would
+// likely panic.
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// Object renders val through its MarshalLog.
+func Object(key string, val LogMarshaler) Field {
+	return Field{Key: key, Type: ObjectType, Interface: val}
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"errors"
+	"strings"
+)
+
+// ContinuationMarker starts the continuation lines of a MultilineIndent
+// body, a line starting with it belongs to the record above.
+const ContinuationMarker = "\t| "
+
+// Multi-line body modes of LogMultiline.
+const (
+	MultilineRaw    = "raw"    // the body as is, the default
+	MultilineEscape = "escape" // \n, \r, \t, \\ and \xHH for the other control characters
+	MultilineIndent = "indent" // every \n followed by ContinuationMarker
+)
+
+const hexDigits = "0123456789abcdef"
+
+var errBadEscape = errors.New("jlog: bad escape sequence")
+
+// _FoldBody rewrites the body of buf from start as the multi-line mode of
+// the logger asks, in place from the end so nothing is allocated.
+func (l *logger) _FoldBody(buf *Buffer, start int) {
+	escape := l.multiline == MultilineEscape
+	extra := 0
+	for _, c := range buf.Bytes()[start:] {
+		switch {
+		case c == '\n' && !escape:
+			extra += len(ContinuationMarker)
+		case !escape:
+		case c == '\\' || c == '\n' || c == '\r' || c == '\t':
+			extra++
+		case c < 0x20 || c == 0x7f:
+			extra += 3
+		}
+	}
+	if extra == 0 {
+		return
+	}
+
+	n := buf.Len()
+	for i := 0; i < extra; i++ {
+		_ = buf.WriteByte(0)
+	}
+	b := buf.Bytes()
+	w := len(b)
+	for r := n - 1; r >= start && w > r; r-- {
+		c := b[r]
+		switch {
+		case c == '\n' && !escape:
+			w -= len(ContinuationMarker)
+			copy(b[w:], ContinuationMarker)
+			w--
+			b[w] = c
+		case !escape || (c >= 0x20 && c != 0x7f && c != '\\'):
+			w--
+			b[w] = c
+		case c < 0x20 && c != '\n' && c != '\r' && c != '\t' || c == 0x7f:
+			w -= 4
+			b[w], b[w+1], b[w+2], b[w+3] = '\\', 'x', hexDigits[c>>4], hexDigits[c&0xF]
+		default:
+			w -= 2
+			b[w], b[w+1] = '\\', escapeLetter(c)
+		}
+	}
+}
+
+func escapeLetter(c byte) byte {
+	switch c {
+	case '\n':
+		return 'n'
+	case '\r':
+		return 'r'
+	case '\t':
+		return 't'
+	}
+	return c
+}
+
+// UnescapeBody returns the body written by MultilineEscape as it was
+// logged.
+func UnescapeBody(s string) (string, error) {
+	i := strings.IndexByte(s, '\\')
+	if i < 0 {
+		return s, nil
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	b.WriteString(s[:i])
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 == len(s) {
+			return "", errBadEscape
+		}
+		i++
+		switch s[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'x':
+			if i+2 >= len(s) {
+				return "", errBadEscape
+			}
+			hi, lo := unhex(s[i+1]), unhex(s[i+2])
+			if hi < 0 || lo < 0 {
+				return "", errBadEscape
+			}
+			b.WriteByte(byte(hi<<4 | lo))
+			i += 2
+		default:
+			return "", errBadEscape
+		}
+	}
+	return b.String(), nil
+}
+
+// JoinContinuation returns the body written by MultilineIndent from its
+// lines, the continuation ones still starting with ContinuationMarker.
+func JoinContinuation(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+			line = strings.TrimPrefix(line, ContinuationMarker)
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func unhex(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c - 'a' + 10)
+	case 'A' <= c && c <= 'F':
+		return int(c - 'A' + 10)
+	}
+	return -1
+}
@@ -4,11 +4,17 @@
 
 package jlog
 
+import (
+	"io"
+	"regexp"
+	"time"
+)
+
 type Option func(l *logger)
 
 func LogLevel(lv Level) Option {
 	opt := func(l *logger) {
-		l.file.level = lv
+		l.file._SetLevel(lv)
 	}
 	return opt
 }
@@ -30,9 +36,198 @@ func _LogDebug(debug bool) Option {
 }
 
 
+// LogAsyncFormat formats the records of Output and Outputf on the writer
+// goroutine instead of the calling one. It takes the formatting cost off the
+// caller, but the arguments are read after the log call returned, so they
+// must not be modified afterwards.
+func LogAsyncFormat(async bool) Option {
+	opt := func(l *logger) {
+		l.async = async
+	}
+	return opt
+}
+
+// LogTimeFormat sets the time format of the record header, one of the
+// TimeFormat constants or a time layout such as time.RFC3339Nano.
+func LogTimeFormat(layout string) Option {
+	opt := func(l *logger) {
+		l.timeLayout = layout
+	}
+	return opt
+}
+
+// LogMultiline sets how the line breaks of a body are written, one of the
+// Multiline constants, MultilineRaw by default. The empty and the unknown
+// modes stand for MultilineRaw.
+func LogMultiline(mode string) Option {
+	opt := func(l *logger) {
+		switch mode {
+		case MultilineEscape, MultilineIndent:
+			l.multiline = mode
+		default:
+			l.multiline = MultilineRaw
+		}
+	}
+	return opt
+}
+
+// LogTimeZone sets the time zone of the record header and of the time in
+// the rotated file names, the local one by default.
+func LogTimeZone(loc *time.Location) Option {
+	opt := func(l *logger) {
+		l.file.loc = loc
+	}
+	return opt
+}
+
+// LogClock makes the logger take the time from c instead of time.Now.
+func LogClock(c Clock) Option {
+	opt := func(l *logger) {
+		if c != nil {
+			l.file.clock = c
+		}
+	}
+	return opt
+}
+
+// LogPrefixLevels caps the level of the records of each prefix the way
+// LogLevel caps the logger, prefixes not listed only follow LogLevel.
+func LogPrefixLevels(levels map[string]Level) Option {
+	opt := func(l *logger) {
+		l._SetPrefixLevels(levels)
+	}
+	return opt
+}
+
+// LogSampling keeps the first initial records of each level every second,
+// then one in thereafter, zero drops the rest. Initial zero turns it off.
+func LogSampling(initial, thereafter int) Option {
+	opt := func(l *logger) {
+		l._SetSampling(initial, thereafter)
+	}
+	return opt
+}
+
+// LogRedactFields renders the values of the typed fields and of the
+// LogMarshaler pairs named keys as Redacted, and those of the adapters
+// checking RedactedKey.
+func LogRedactFields(keys ...string) Option {
+	opt := func(l *logger) {
+		if l.redactKeys == nil {
+			l.redactKeys = make(map[string]struct{}, len(keys))
+		}
+		for _, key := range keys {
+			l.redactKeys[key] = struct{}{}
+		}
+	}
+	return opt
+}
+
+// LogScrub replaces the matches of re in the rendered body of every record
+// with repl, which may refer to the submatches like regexp.ReplaceAll, an
+// empty repl stands for Redacted. The header and the caller are left as is.
+func LogScrub(re *regexp.Regexp, repl string) Option {
+	opt := func(l *logger) {
+		if repl == "" {
+			repl = Redacted
+		}
+		l.scrubbers = append(l.scrubbers, scrubber{re: re, repl: []byte(repl)})
+	}
+	return opt
+}
+
+// LogCaller appends the [file:line] of the log call to every record, which
+// dev builds always do.
+func LogCaller(caller bool) Option {
+	opt := func(l *logger) {
+		l.caller = caller
+	}
+	return opt
+}
+
+// LogMaxSize rotates a file once it holds size bytes, MaxSize by default.
+func LogMaxSize(size int) Option {
+	opt := func(l *logger) {
+		if size > 0 {
+			l.file.maxSize = size
+		}
+	}
+	return opt
+}
+
+// LogRetention removes the rotated files of a level once they are older
+// than maxAge or beyond the newest maxFiles, zero keeps them.
+func LogRetention(maxAge time.Duration, maxFiles int) Option {
+	opt := func(l *logger) {
+		l.file.maxAge = maxAge
+		l.file.maxFiles = maxFiles
+	}
+	return opt
+}
+
+// LogSink writes the records of level lv and above to w as well, w is not
+// closed by the logger.
+func LogSink(lv Level, w io.Writer) Option {
+	opt := func(l *logger) {
+		if lv < MinLevel || lv >= MaxLevel || w == nil {
+			return
+		}
+		l.file.sinkOut[lv] = append(l.file.sinkOut[lv], w)
+	}
+	return opt
+}
+
+// _LogCloser closes c along with the logger.
+func _LogCloser(c io.Closer) Option {
+	opt := func(l *logger) {
+		l.file.closers = append(l.file.closers, c)
+	}
+	return opt
+}
+
+// LogColor forces the ANSI colors of the console output on or off, they are
+// used by default only when the console is a terminal. Files never get them.
+func LogColor(color bool) Option {
+	opt := func(l *logger) {
+		l.file.color = colorOff
+		if color {
+			l.file.color = colorOn
+		}
+	}
+	return opt
+}
+
+// LogSync writes every record on the calling goroutine and flushes it
+// before the log call returns, without a writer goroutine. Nothing is lost
+// when the process exits without Close, at the cost of a write per record.
+func LogSync(sync bool) Option {
+	opt := func(l *logger) {
+		l.sync = sync
+	}
+	return opt
+}
+
 func _LogStd(std bool) Option {
 	opt := func(l *logger) {
 		l.std = std
 	}
 	return opt
 }
+
+// LogConsole writes the records to the console as well, ERROR to stderr and
+// the other levels to stdout, each record only to the target of its level.
+// Without LogDir the logger writes to the console only.
+func LogConsole(console bool) Option { return _LogStd(console) }
+
+// LogConsoleWriter sends the console records of level lv to w instead of
+// stdout or stderr, ioutil.Discard mutes the level. It turns LogConsole on.
+func LogConsoleWriter(lv Level, w io.Writer) Option {
+	opt := func(l *logger) {
+		if lv < MinLevel || lv >= MaxLevel {
+			return
+		}
+		l.std = true
+		l.file.consoleOut[lv] = w
+	}
+	return opt
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reader
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+const hourLayout = "2006010215"
+
+// File is a rotated file of jlog, appName.YYYYMMDDHH.idx.ext.log, maybe
+// compressed with gzip as appName.YYYYMMDDHH.idx.ext.log.gz.
+type File struct {
+	Path  string
+	App   string
+	Hour  time.Time
+	Index int
+	Level jlog.Level
+}
+
+// ParseFileName parses the rotated file name of path, reporting false for
+// the other files, the logName.ext symlinks among them.
+func ParseFileName(path string, opts ...Option) (File, bool) {
+	o := newOptions(opts)
+	return o.parseFileName(path)
+}
+
+func (o *options) parseFileName(path string) (f File, ok bool) {
+	name := strings.TrimSuffix(filepath.Base(path), ".gz")
+	if !strings.HasSuffix(name, ".log") {
+		return
+	}
+	parts := strings.Split(strings.TrimSuffix(name, ".log"), ".")
+	n := len(parts)
+	if n < 4 || parts[n-4] == "" {
+		return
+	}
+	if f.Level = jlog.ExtentLevel(parts[n-1]); f.Level < 0 {
+		return
+	}
+	idx, err := strconv.Atoi(parts[n-2])
+	if err != nil || idx < 0 {
+		return
+	}
+	if len(parts[n-3]) != len(hourLayout) {
+		return
+	}
+	if f.Hour, err = time.ParseInLocation(hourLayout, parts[n-3], o.loc); err != nil {
+		return
+	}
+	f.Path, f.App, f.Index = path, strings.Join(parts[:n-3], "."), idx
+	return f, true
+}
+
+// Files returns the rotated files of dir in chronological order, the files
+// of an hour by index then level.
+func Files(dir string, opts ...Option) ([]File, error) {
+	o := newOptions(opts)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if f, ok := o.parseFileName(filepath.Join(dir, e.Name())); ok {
+			files = append(files, f)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := &files[i], &files[j]
+		switch {
+		case !a.Hour.Equal(b.Hour):
+			return a.Hour.Before(b.Hour)
+		case a.Index != b.Index:
+			return a.Index < b.Index
+		case a.Level != b.Level:
+			return a.Level < b.Level
+		}
+		return a.App < b.App
+	})
+	return files, nil
+}
+
+// Open opens the file at path, decompressing the .gz ones.
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: zr, f: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Reader.Close()
+	if e := g.f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// Sets groups files by their app and level, each set in the order of
+// files, Files returns them in chronological order.
+func Sets(files []File) [][]File {
+	type key struct {
+		app string
+		lv  jlog.Level
+	}
+	var (
+		sets  [][]File
+		index = map[key]int{}
+	)
+	for _, f := range files {
+		k := key{f.App, f.Level}
+		i, ok := index[k]
+		if !ok {
+			i = len(sets)
+			index[k] = i
+			sets = append(sets, nil)
+		}
+		sets[i] = append(sets[i], f)
+	}
+	return sets
+}
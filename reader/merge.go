@@ -0,0 +1,126 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reader
+
+import (
+	"io"
+	"time"
+)
+
+// mergeSkew bounds how far back in time a Reader may go, the records of a
+// file are in the order they were queued, not of their time. The copies of
+// a record further apart are returned twice.
+const mergeSkew = 10 * time.Second
+
+// Merger merges the records of several Readers in chronological order. A
+// record goes to the files of its level and below, so a record read from
+// several Readers at the same time is returned once.
+type Merger struct {
+	rs     []*Reader
+	heads  []Record
+	ready  []bool
+	done   []bool
+	high   []time.Time // the latest time read from each Reader
+	pruned time.Time   // seen holds no record before it
+	seen   map[Record]*seen
+}
+
+// seen counts the copies of a record per Reader, a Reader holding n copies
+// of it at the same time means n records.
+type seen struct {
+	emitted int
+	counts  []int
+}
+
+// Merge returns the Merger of rs, ties going to the first one.
+func Merge(rs ...*Reader) *Merger {
+	return &Merger{
+		rs:    rs,
+		heads: make([]Record, len(rs)),
+		ready: make([]bool, len(rs)),
+		done:  make([]bool, len(rs)),
+		high:  make([]time.Time, len(rs)),
+		seen:  map[Record]*seen{},
+	}
+}
+
+// Next returns the next record, io.EOF after the last one. The errors of
+// the Readers are returned as they come, reading may go on after a
+// *SyntaxError.
+func (m *Merger) Next() (Record, error) {
+	for {
+		min := -1
+		for i, r := range m.rs {
+			if !m.ready[i] && !m.done[i] {
+				rec, err := r.Next()
+				if err == io.EOF {
+					m.done[i] = true
+					continue
+				}
+				if err != nil {
+					return Record{}, err
+				}
+				m.heads[i], m.ready[i] = rec, true
+				if rec.Time.After(m.high[i]) {
+					m.high[i] = rec.Time
+				}
+			}
+			if m.ready[i] && (min < 0 || m.heads[i].Time.Before(m.heads[min].Time)) {
+				min = i
+			}
+		}
+		if min < 0 {
+			return Record{}, io.EOF
+		}
+
+		rec := m.heads[min]
+		m.ready[min] = false
+		m._Prune()
+		s := m.seen[rec]
+		if s == nil {
+			s = &seen{counts: make([]int, len(m.rs))}
+			m.seen[rec] = s
+		}
+		if s.counts[min]++; s.counts[min] > s.emitted {
+			s.emitted++
+			return rec, nil
+		}
+	}
+}
+
+// _Prune forgets the records every Reader has left mergeSkew behind, once
+// they are mergeSkew past the last pruning.
+func (m *Merger) _Prune() {
+	var low time.Time
+	for i := range m.rs {
+		if !m.done[i] && (low.IsZero() || m.high[i].Before(low)) {
+			low = m.high[i]
+		}
+	}
+	if low.IsZero() {
+		return // a Reader has no record yet
+	}
+	until := low.Add(-mergeSkew)
+	if until.Sub(m.pruned) < mergeSkew {
+		return
+	}
+	for k := range m.seen {
+		if k.Time.Before(until) {
+			delete(m.seen, k)
+		}
+	}
+	m.pruned = until
+}
+
+// Close closes the Readers.
+func (m *Merger) Close() error {
+	var err error
+	for _, r := range m.rs {
+		if e := r.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
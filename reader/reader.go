@@ -0,0 +1,306 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package reader reads back the text files written by jlog, records of the
+// form
+//
+//	yyyymmdd hh:mm:ss.uuuuuu [L]:[prefix]body [file:line]
+//
+// the lines without a header continuing the body of the record above.
+package reader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tiger-game/jlog"
+)
+
+const glogLayout = "20060102 15:04:05.000000"
+
+// Record is a record of a jlog text file.
+type Record struct {
+	Time   time.Time
+	Level  jlog.Level
+	Prefix string
+	Body   string
+	File   string // the caller, empty when not logged
+	Line   int
+}
+
+// SyntaxError reports a line which is neither a record nor the
+// continuation of one.
+type SyntaxError struct {
+	Line int // 1-based, counted over all the files of the Reader
+	Text string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("reader: line %d: no record header in %q", e.Line, e.Text)
+}
+
+var errNoHeader = errors.New("no record header")
+
+type options struct {
+	loc      *time.Location
+	layout   string
+	unescape bool
+	indent   bool
+}
+
+type Option func(o *options)
+
+// Location sets the time zone of the times without one, the file names and
+// the glog headers, the local one by default.
+func Location(loc *time.Location) Option {
+	return func(o *options) {
+		o.loc = loc
+	}
+}
+
+// TimeFormat sets the jlog.LogTimeFormat the files were written with.
+func TimeFormat(layout string) Option {
+	return func(o *options) {
+		o.layout = layout
+	}
+}
+
+// Unescape decodes the bodies written with jlog.MultilineEscape.
+func Unescape(unescape bool) Option {
+	return func(o *options) {
+		o.unescape = unescape
+	}
+}
+
+// Indent strips the jlog.ContinuationMarker of the continuation lines of the
+// bodies written with jlog.MultilineIndent, the other ones are kept as is.
+func Indent(indent bool) Option {
+	return func(o *options) {
+		o.indent = indent
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{loc: time.Local, layout: jlog.TimeFormatGlog}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ParseLine parses a single line record, without its line break.
+func ParseLine(line string, opts ...Option) (Record, error) {
+	o := newOptions(opts)
+	return o.parse(line, nil)
+}
+
+// parse parses the record of the header line first and of its continuation
+// lines.
+func (o *options) parse(first string, more []string) (Record, error) {
+	var rec Record
+	t, lv, rest, ok := o.header(first)
+	if !ok {
+		return rec, errNoHeader
+	}
+	rec.Time, rec.Level = t, lv
+
+	body := rest
+	if len(more) > 0 {
+		lines := append([]string{rest}, more...)
+		if o.indent {
+			body = jlog.JoinContinuation(lines)
+		} else {
+			body = strings.Join(lines, "\n")
+		}
+	}
+	if strings.HasPrefix(body, "[") {
+		if i := strings.IndexByte(body, ']'); i > 1 && !strings.ContainsAny(body[1:i], " [") {
+			rec.Prefix, body = body[1:i], body[i+1:]
+		}
+	}
+	body, rec.File, rec.Line = splitCaller(body)
+	if o.unescape {
+		var err error
+		if body, err = jlog.UnescapeBody(body); err != nil {
+			return rec, err
+		}
+	}
+	rec.Body = body
+	return rec, nil
+}
+
+// header splits line after its " [L]:", reporting false for the lines
+// which do not start with a record header.
+func (o *options) header(line string) (t time.Time, lv jlog.Level, rest string, ok bool) {
+	i := strings.Index(line, "]:")
+	if i < 3 || line[i-3] != ' ' || line[i-2] != '[' {
+		return
+	}
+	if lv = flagLevel(line[i-1]); lv < 0 {
+		return
+	}
+
+	var err error
+	stamp := line[:i-3]
+	switch o.layout {
+	case jlog.TimeFormatGlog:
+		t, err = time.ParseInLocation(glogLayout, stamp, o.loc)
+	case jlog.TimeFormatUnixMilli, jlog.TimeFormatUnixNano:
+		var n int64
+		if n, err = strconv.ParseInt(stamp, 10, 64); err == nil {
+			if o.layout == jlog.TimeFormatUnixMilli {
+				n *= int64(time.Millisecond)
+			}
+			t = time.Unix(0, n).In(o.loc)
+		}
+	default:
+		t, err = time.ParseInLocation(o.layout, stamp, o.loc)
+	}
+	if err != nil {
+		return
+	}
+	return t, lv, line[i+2:], true
+}
+
+func flagLevel(flag byte) jlog.Level {
+	for lv, f := range jlog.LevelFlags {
+		if f == flag {
+			return jlog.Level(lv)
+		}
+	}
+	return jlog.Level(-1)
+}
+
+// splitCaller cuts the " [file:line]" jlog appends to body.
+func splitCaller(body string) (string, string, int) {
+	if !strings.HasSuffix(body, "]") {
+		return body, "", 0
+	}
+	i := strings.LastIndex(body, " [")
+	if i < 0 {
+		return body, "", 0
+	}
+	loc := body[i+2 : len(body)-1]
+	j := strings.LastIndexByte(loc, ':')
+	if j <= 0 || strings.ContainsAny(loc, " \n") {
+		return body, "", 0
+	}
+	line, err := strconv.Atoi(loc[j+1:])
+	if err != nil || line < 0 {
+		return body, "", 0
+	}
+	return body[:i], loc[:j], line
+}
+
+// Reader reads the records of a jlog text stream, or of a set of files one
+// after the other.
+type Reader struct {
+	opts  options
+	br    *bufio.Reader
+	src   io.Closer
+	files []File
+	next  string // the header line read ahead
+	ahead bool
+	line  int
+	file  int // the count of the files opened
+	more  []string
+}
+
+// NewReader returns a Reader of the records of r.
+func NewReader(r io.Reader, opts ...Option) *Reader {
+	return &Reader{opts: newOptions(opts), br: bufio.NewReaderSize(r, 64<<10)}
+}
+
+// OpenFiles returns a Reader of the records of files, in their order, each
+// file opened once the previous one is read.
+func OpenFiles(files []File, opts ...Option) *Reader {
+	return &Reader{opts: newOptions(opts), files: files}
+}
+
+// Next returns the next record, io.EOF after the last one. A *SyntaxError
+// is returned for each line before the first record, reading may go on.
+func (r *Reader) Next() (Record, error) {
+	if !r.ahead {
+		line, err := r.readLine()
+		if err != nil {
+			return Record{}, err
+		}
+		r.next = line
+	}
+	r.ahead = false
+	first, n := r.next, r.line
+	if _, _, _, ok := r.opts.header(first); !ok {
+		// a line of no record, its continuation lines are orphans too.
+		return Record{}, &SyntaxError{Line: n, Text: first}
+	}
+
+	r.more = r.more[:0]
+	for file := r.file; ; {
+		line, err := r.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Record{}, err
+		}
+		// a record does not go on in the next file.
+		if _, _, _, ok := r.opts.header(line); ok || r.file != file {
+			r.next, r.ahead = line, true
+			break
+		}
+		r.more = append(r.more, line)
+	}
+
+	return r.opts.parse(first, r.more)
+}
+
+// readLine returns the next line without its line break, going over to the
+// next file at the end of one.
+func (r *Reader) readLine() (string, error) {
+	for {
+		if r.br == nil {
+			if len(r.files) == 0 {
+				return "", io.EOF
+			}
+			rc, err := Open(r.files[0].Path)
+			if err != nil {
+				return "", err
+			}
+			r.files = r.files[1:]
+			r.src, r.br = rc, bufio.NewReaderSize(rc, 64<<10)
+			r.file++
+		}
+
+		line, err := r.br.ReadString('\n')
+		if line != "" {
+			r.line++
+			line = strings.TrimSuffix(line, "\n")
+			return line, nil
+		}
+		if err != io.EOF {
+			return "", err
+		}
+		if r.src == nil {
+			return "", io.EOF // NewReader
+		}
+		if err = r.Close(); err != nil {
+			return "", err
+		}
+	}
+}
+
+// Close closes the open file of OpenFiles.
+func (r *Reader) Close() error {
+	var err error
+	if r.src != nil {
+		err = r.src.Close()
+	}
+	r.src, r.br = nil, nil
+	return err
+}
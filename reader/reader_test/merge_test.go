@@ -0,0 +1,151 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reader_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/jlogtest"
+	"github.com/tiger-game/jlog/reader"
+)
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlogtest.NewClock(time.Date(2026, 10, 18, 9, 59, 30, 0, time.UTC))
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogClock(clock),
+		jlog.LogTimeZone(time.UTC), jlog.LogSync(true))
+	want := []struct {
+		lv   jlog.Level
+		body string
+	}{
+		{jlog.INFO, "start"},
+		{jlog.ERROR, "failed"},
+		{jlog.ERROR, "failed"}, // twice at the same time
+		{jlog.WARN, "retry"},
+		{jlog.INFO, "next hour"},
+		{jlog.ERROR, "failed again"},
+	}
+	for i, w := range want {
+		l.Outputf(w.lv, "", 0, "%s", w.body)
+		if i != 1 {
+			clock.Add(10 * time.Second)
+		}
+	}
+	l.Close()
+
+	files, err := reader.Files(dir, reader.Location(time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := reader.Sets(files)
+	if len(sets) < 3 {
+		t.Fatalf("want the sets of several levels, got %d", len(sets))
+	}
+	var rs []*reader.Reader
+	for _, set := range sets {
+		rs = append(rs, reader.OpenFiles(set, reader.Location(time.UTC)))
+	}
+	m := reader.Merge(rs...)
+	defer m.Close()
+
+	for i := 0; ; i++ {
+		rec, err := m.Next()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Fatalf("merged %d records, want %d", i, len(want))
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) || rec.Level != want[i].lv || rec.Body != want[i].body {
+			t.Fatalf("record %d = %+v", i, rec)
+		}
+	}
+}
+
+// mergeAll returns the bodies of the records of m.
+func mergeAll(t *testing.T, m *reader.Merger) []string {
+	t.Helper()
+	var bodies []string
+	for {
+		rec, err := m.Next()
+		if err == io.EOF {
+			return bodies
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, rec.Body)
+	}
+}
+
+func TestMergeOutOfOrder(t *testing.T) {
+	// the writer queued A before B, whose time was taken first.
+	text := "20261018 09:05:02.000000 [E]:A\n20261018 09:05:01.000000 [E]:B\n"
+	m := reader.Merge(reader.NewReader(strings.NewReader(text), reader.Location(time.UTC)),
+		reader.NewReader(strings.NewReader(text), reader.Location(time.UTC)))
+	if got := strings.Join(mergeAll(t, m), ","); got != "A,B" {
+		t.Fatalf("merged %s, want A,B", got)
+	}
+
+	text = "20261018 09:05:02.000000 [E]:A\n20261018 09:05:01.000000 [E]:B\n20261018 09:05:02.000000 [E]:A\n"
+	m = reader.Merge(reader.NewReader(strings.NewReader(text), reader.Location(time.UTC)),
+		reader.NewReader(strings.NewReader(text), reader.Location(time.UTC)))
+	if got := strings.Join(mergeAll(t, m), ","); got != "A,B,A" {
+		t.Fatalf("merged %s, want A,B,A", got)
+	}
+}
+
+func TestMergeConcurrent(t *testing.T) {
+	const producers, records = 64, 500
+	dir := t.TempDir()
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR))
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				l.Outputf(jlog.INFO+jlog.Level(i%3), "", 0, "p%d r%d", p, i)
+			}
+		}(p)
+	}
+	wg.Wait()
+	l.Close()
+
+	files, err := reader.Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rs []*reader.Reader
+	for _, set := range reader.Sets(files) {
+		rs = append(rs, reader.OpenFiles(set))
+	}
+	m := reader.Merge(rs...)
+	defer m.Close()
+
+	got := map[string]int{}
+	for _, body := range mergeAll(t, m) {
+		got[body]++
+	}
+	for p := 0; p < producers; p++ {
+		for i := 0; i < records; i++ {
+			if body := fmt.Sprintf("p%d r%d", p, i); got[body] != 1 {
+				t.Fatalf("%s merged %d times", body, got[body])
+			}
+		}
+	}
+	if len(got) != producers*records {
+		t.Fatalf("merged %d records, want %d", len(got), producers*records)
+	}
+}
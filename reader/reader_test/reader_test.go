@@ -0,0 +1,242 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reader_test
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiger-game/jlog"
+	"github.com/tiger-game/jlog/jlogtest"
+	"github.com/tiger-game/jlog/reader"
+)
+
+func TestParseLine(t *testing.T) {
+	at := time.Date(2026, 10, 18, 9, 5, 7, 123456000, time.UTC)
+	for _, c := range []struct {
+		line string
+		opts []reader.Option
+		want reader.Record
+	}{
+		{"20261018 09:05:07.123456 [I]:[db]query took 3ms [store.go:42]", nil,
+			reader.Record{Time: at, Level: jlog.INFO, Prefix: "db", Body: "query took 3ms", File: "store.go", Line: 42}},
+		{"20261018 09:05:07.123456 [E]:disk full", nil,
+			reader.Record{Time: at, Level: jlog.ERROR, Body: "disk full"}},
+		{"20261018 09:05:07.123456 [W]:[not a prefix] body [a b:1]", nil,
+			reader.Record{Time: at, Level: jlog.WARN, Body: "[not a prefix] body [a b:1]"}},
+		{"20261018 09:05:07.123456 [D]:", nil,
+			reader.Record{Time: at, Level: jlog.DEBUG}},
+		{"1792314307123 [I]:ms", []reader.Option{reader.TimeFormat(jlog.TimeFormatUnixMilli)},
+			reader.Record{Time: at.Truncate(time.Millisecond), Level: jlog.INFO, Body: "ms"}},
+		{"2026-10-18T09:05:07.123456Z [I]:rfc [x.go:7]", []reader.Option{reader.TimeFormat(time.RFC3339Nano)},
+			reader.Record{Time: at, Level: jlog.INFO, Body: "rfc", File: "x.go", Line: 7}},
+		{`20261018 09:05:07.123456 [I]:a\nb\\c`, []reader.Option{reader.Unescape(true)},
+			reader.Record{Time: at, Level: jlog.INFO, Body: "a\nb\\c"}},
+	} {
+		got, err := reader.ParseLine(c.line, append(c.opts, reader.Location(time.UTC))...)
+		if err != nil {
+			t.Errorf("ParseLine(%q): %v", c.line, err)
+			continue
+		}
+		if !got.Time.Equal(c.want.Time) {
+			t.Errorf("ParseLine(%q) time %v, want %v", c.line, got.Time, c.want.Time)
+		}
+		got.Time = c.want.Time
+		if got != c.want {
+			t.Errorf("ParseLine(%q) = %+v, want %+v", c.line, got, c.want)
+		}
+	}
+
+	for _, line := range []string{"", "plain text", "20261018 09:05:07.123456 [X]:bad level", "yesterday [I]:bad time"} {
+		if _, err := reader.ParseLine(line); err == nil {
+			t.Errorf("ParseLine(%q) succeeded", line)
+		}
+	}
+}
+
+func TestReaderContinuation(t *testing.T) {
+	text := "garbage\n" +
+		"20261018 09:05:07.123456 [E]:raw\n\tat main.go:10 [main.go:10]\n" +
+		"20261018 09:05:08.000000 [E]:indent\n" + jlog.ContinuationMarker + "next [main.go:11]\n" +
+		"20261018 09:05:09.000000 [I]:last"
+	for _, indent := range []bool{false, true} {
+		r := reader.NewReader(strings.NewReader(text), reader.Location(time.UTC), reader.Indent(indent))
+
+		var serr *reader.SyntaxError
+		if _, err := r.Next(); !errors.As(err, &serr) || serr.Line != 1 {
+			t.Fatalf("Next() error %v, want a SyntaxError of line 1", err)
+		}
+		// the marker is the body of a raw one.
+		second := "indent\n" + jlog.ContinuationMarker + "next"
+		if indent {
+			second = "indent\nnext"
+		}
+		for _, want := range []reader.Record{
+			{Level: jlog.ERROR, Body: "raw\n\tat main.go:10", File: "main.go", Line: 10},
+			{Level: jlog.ERROR, Body: second, File: "main.go", Line: 11},
+			{Level: jlog.INFO, Body: "last"},
+		} {
+			got, err := r.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got.Time = time.Time{}
+			if got != want {
+				t.Errorf("Indent(%v) Next() = %+v, want %+v", indent, got, want)
+			}
+		}
+		if _, err := r.Next(); err != io.EOF {
+			t.Fatalf("Next() error %v, want EOF", err)
+		}
+	}
+}
+
+// TestReaderFileBoundary reads a file starting with a line of no header
+// after one ending with a record, the line is not its continuation.
+func TestReaderFileBoundary(t *testing.T) {
+	dir := t.TempDir()
+	var files []reader.File
+	for i, text := range []string{"20261018 09:05:07.123456 [I]:first\n", "orphan\n20261018 09:05:08.000000 [I]:second\n"} {
+		path := filepath.Join(dir, strconv.Itoa(i)+".log")
+		if err := ioutil.WriteFile(path, []byte(text), 0664); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, reader.File{Path: path})
+	}
+	r := reader.OpenFiles(files, reader.Location(time.UTC))
+	defer r.Close()
+
+	if rec, err := r.Next(); err != nil || rec.Body != "first" {
+		t.Fatalf("Next() = %+v, %v, want the first record alone", rec, err)
+	}
+	var serr *reader.SyntaxError
+	if _, err := r.Next(); !errors.As(err, &serr) || serr.Line != 2 || serr.Text != "orphan" {
+		t.Fatalf("Next() error %v, want a SyntaxError of line 2", err)
+	}
+	if rec, err := r.Next(); err != nil || rec.Body != "second" {
+		t.Fatalf("Next() = %+v, %v, want the second record", rec, err)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("Next() error %v, want EOF", err)
+	}
+}
+
+func TestReaderOrphanLines(t *testing.T) {
+	text := "foreign\nfile\nlines\n20261018 09:05:07.123456 [I]:record\n"
+	r := reader.NewReader(strings.NewReader(text), reader.Location(time.UTC))
+	for i, want := range []string{"foreign", "file", "lines"} {
+		var serr *reader.SyntaxError
+		if _, err := r.Next(); !errors.As(err, &serr) || serr.Line != i+1 || serr.Text != want {
+			t.Fatalf("Next() error %v, want a SyntaxError of line %d %q", err, i+1, want)
+		}
+	}
+	if rec, err := r.Next(); err != nil || rec.Body != "record" {
+		t.Fatalf("Next() = %+v, %v, want the record", rec, err)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("Next() error %v, want io.EOF", err)
+	}
+}
+
+func TestParseFileName(t *testing.T) {
+	f, ok := reader.ParseFileName("/var/log/my.app.2026101809.3.wrn.log.gz", reader.Location(time.UTC))
+	want := reader.File{Path: "/var/log/my.app.2026101809.3.wrn.log.gz", App: "my.app",
+		Hour: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC), Index: 3, Level: jlog.WARN}
+	if !ok || f != want {
+		t.Fatalf("ParseFileName = %+v, %v, want %+v", f, ok, want)
+	}
+	for _, name := range []string{"app.inf", "app.2026101809.0.xyz.log", "app.20261018.0.inf.log", ".2026101809.0.inf.log", "app.2026101809.x.inf.log"} {
+		if _, ok := reader.ParseFileName(name); ok {
+			t.Errorf("ParseFileName(%q) succeeded", name)
+		}
+	}
+}
+
+// TestRotatedSet reads back the records of a logger across hourly and size
+// rotations, one of the files compressed.
+func TestRotatedSet(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlogtest.NewClock(time.Date(2026, 10, 18, 9, 59, 0, 0, time.UTC))
+	l := jlog.NewLogger(jlog.LogDir(dir), jlog.LogLevel(jlog.ERROR), jlog.LogClock(clock),
+		jlog.LogTimeZone(time.UTC), jlog.LogMaxSize(200), jlog.LogSync(true))
+	var want []string
+	for i := 0; i < 20; i++ {
+		body := fmt.Sprintf("record %d", i)
+		l.Outputf(jlog.WARN, "srv", 0, "%s", body)
+		want = append(want, body)
+		clock.Add(10 * time.Second)
+	}
+	l.Close()
+
+	files, err := reader.Files(dir, reader.Location(time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set []reader.File
+	for _, f := range files {
+		if f.Level == jlog.WARN {
+			set = append(set, f)
+		}
+	}
+	if len(set) < 3 || set[0].Hour.Equal(set[len(set)-1].Hour) {
+		t.Fatalf("want several files over two hours, got %+v", set)
+	}
+	set[0].Path = gzipFile(t, set[0].Path)
+
+	r := reader.OpenFiles(set, reader.Location(time.UTC))
+	defer r.Close()
+	var last time.Time
+	for i := 0; ; i++ {
+		rec, err := r.Next()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Fatalf("read %d records, want %d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Prefix != "srv" || rec.Level != jlog.WARN || rec.Body != want[i] || rec.Time.Before(last) {
+			t.Fatalf("record %d = %+v, want %q", i, rec, want[i])
+		}
+		last = rec.Time
+	}
+}
+
+func gzipFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	if _, err = zw.Write(data); err == nil {
+		err = zw.Close()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(filepath.Dir(path), filepath.Base(path)+".gz")
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the values hidden by the redaction rules: the struct
+// fields tagged `jlog:"redact"`, the fields named by LogRedactFields and the
+// matches of LogScrub without a replacement.
+const Redacted = "[REDACTED]"
+
+var redactFields sync.Map // reflect.Type -> []structField
+
+type scrubber struct {
+	re   *regexp.Regexp
+	repl []byte
+}
+
+type structField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+	redact    bool
+}
+
+// cachedFields returns the fields of the struct type t as encoding/json
+// sees them, the embedded structs flattened.
+func cachedFields(t reflect.Type) []structField {
+	if v, ok := redactFields.Load(t); ok {
+		return v.([]structField)
+	}
+	fields := appendStructFields(nil, t, nil)
+	redactFields.Store(t, fields)
+	return fields
+}
+
+func appendStructFields(fields []structField, t reflect.Type, index []int) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			name, opts = tag[:j], tag[j:]
+		}
+		idx := append(append([]int(nil), index...), i)
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = appendStructFields(fields, ft, idx)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, structField{
+			index:     idx,
+			name:      name,
+			omitEmpty: strings.Contains(opts, ",omitempty"),
+			redact:    sf.Tag.Get("jlog") == "redact",
+		})
+	}
+	return fields
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, reporting false for a field
+// of a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// _AppendFields is appendFields2Buffer with the LogRedactFields masked.
+func (l *logger) _AppendFields(buf *Buffer, fields []Field) {
+	if len(l.redactKeys) == 0 {
+		appendFields2Buffer(buf, fields)
+		return
+	}
+	for i := range fields {
+		f := &fields[i]
+		_ = buf.WriteByte(' ')
+		_, _ = buf.WriteString(f.Key)
+		_ = buf.WriteByte('=')
+		if _, ok := l.redactKeys[f.Key]; ok {
+			_, _ = buf.WriteString(Redacted)
+			continue
+		}
+		appendField2Buffer(buf, f)
+	}
+}
+
+// _Scrub runs the LogScrub expressions over the body of buf from start.
+func (l *logger) _Scrub(buf *Buffer, start int) {
+	body := buf.Bytes()[start:]
+	changed := false
+	for _, s := range l.scrubbers {
+		if s.re.Match(body) {
+			body = s.re.ReplaceAll(body, s.repl)
+			changed = true
+		}
+	}
+	if changed {
+		buf.Truncate(start)
+		_, _ = buf.Write(body)
+	}
+}
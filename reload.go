@@ -0,0 +1,212 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// _Reconfigure runs fn where the files are written, after the records
+// queued before the call and before the later ones, and waits for it.
+func (l *logger) _Reconfigure(fn func()) {
+	if l.sync {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !l.isClosed {
+			fn()
+		}
+		return
+	}
+	done := make(chan struct{})
+	if !l._Push(func() { fn(); close(done) }) {
+		return
+	}
+	select {
+	case <-done:
+	case <-l.waitClose:
+	}
+}
+
+// WatchConfig applies the config file at path to the running logger, then
+// polls its modification time every interval and applies the changes. Only
+// the level, the prefix levels, the sampling and the sinks change live, the
+// ones the file leaves out are kept. The sinks of the file replace every
+// sink of the logger, the files of the unchanged ones stay open. The changes
+// are logged to the std logger. The watch ends with stop or with the logger.
+func (l *logger) WatchConfig(path string, interval time.Duration) (stop func(), err error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfig(path)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = l._ApplyConfig(&cfg); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		mtime, size := fi.ModTime(), fi.Size()
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			case <-l.closed:
+				return
+			}
+			fi, err := os.Stat(path)
+			if err != nil || (fi.ModTime().Equal(mtime) && fi.Size() == size) {
+				continue
+			}
+			mtime, size = fi.ModTime(), fi.Size()
+			cfg = l._ReloadConfig(path, cfg)
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
+
+// _ReloadConfig applies the config file at path if it is valid and returns
+// the config in effect.
+func (l *logger) _ReloadConfig(path string, old Config) Config {
+	cfg, err := LoadConfig(path)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err == nil {
+		cfg._Inherit(&old)
+	}
+	if err == nil {
+		err = l._ApplyConfig(&cfg)
+	}
+	if err != nil {
+		_StdLog().Errorf("jlog reload %s: %v, keeping the previous config", path, err)
+		return old
+	}
+
+	live, restart := configDiff(&old, &cfg)
+	for _, change := range live {
+		_StdLog().Infof("jlog reload %s: %s", path, change)
+	}
+	for _, change := range restart {
+		_StdLog().Warnf("jlog reload %s: %s needs a restart", path, change)
+	}
+	return cfg
+}
+
+// _ApplyConfig applies the live fields of the validated cfg, those it
+// leaves out are kept.
+func (l *logger) _ApplyConfig(cfg *Config) (err error) {
+	l._Reconfigure(func() {
+		if cfg.Sinks != nil {
+			var (
+				outs    [MaxLevel][]io.Writer
+				closers []io.Closer
+			)
+			if outs, closers, err = cfg._OpenSinks(l.file._SinkFiles()); err != nil {
+				return
+			}
+			l.file._ResetSinks(outs, closers)
+		}
+		if cfg.PrefixLevels != nil {
+			levels := make(map[string]Level, len(cfg.PrefixLevels))
+			for prefix, name := range cfg.PrefixLevels {
+				levels[prefix], _ = ParseLevel(name)
+			}
+			l._SetPrefixLevels(levels)
+		}
+		if cfg.Sampling != nil {
+			l._SetSampling(cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+		}
+		if cfg.Level != "" {
+			level, _ := ParseLevel(cfg.Level)
+			l.file._SetLevel(level)
+			if l.std {
+				l.file._InitConsole()
+			}
+		}
+	})
+	return err
+}
+
+// _Inherit fills the live fields c leaves out with those of old.
+func (c *Config) _Inherit(old *Config) {
+	if c.Level == "" {
+		c.Level = old.Level
+	}
+	if c.PrefixLevels == nil {
+		c.PrefixLevels = old.PrefixLevels
+	}
+	if c.Sampling == nil {
+		c.Sampling = old.Sampling
+	}
+	if c.Sinks == nil {
+		c.Sinks = old.Sinks
+	}
+}
+
+// configDiff describes the changes from old to cfg, of the live fields and
+// of the ones only read when the logger is created.
+func configDiff(old, cfg *Config) (live, restart []string) {
+	diff := func(list *[]string, field string, from, to interface{}) {
+		if a, b := fmt.Sprint(from), fmt.Sprint(to); a != b {
+			*list = append(*list, fmt.Sprintf("%s: %q -> %q", field, a, b))
+		}
+	}
+
+	diff(&live, "level", old.Level, cfg.Level)
+	keys := sortedKeys(old.PrefixLevels)
+	for _, prefix := range sortedKeys(cfg.PrefixLevels) {
+		if _, ok := old.PrefixLevels[prefix]; !ok {
+			keys = append(keys, prefix)
+		}
+	}
+	sort.Strings(keys)
+	for _, prefix := range keys {
+		diff(&live, "prefixLevels."+prefix, old.PrefixLevels[prefix], cfg.PrefixLevels[prefix])
+	}
+	diff(&live, "sampling", samplingString(old.Sampling), samplingString(cfg.Sampling))
+	diff(&live, "sinks", sinksString(old.Sinks), sinksString(cfg.Sinks))
+
+	diff(&restart, "dir", old.Dir, cfg.Dir)
+	diff(&restart, "rotation", old.Rotation, cfg.Rotation)
+	diff(&restart, "retention", old.Retention, cfg.Retention)
+	diff(&restart, "encoder", old.Encoder, cfg.Encoder)
+	diff(&restart, "console", old.Console, cfg.Console)
+	diff(&restart, "caller", old.Caller, cfg.Caller)
+	diff(&restart, "timeFormat", old.TimeFormat, cfg.TimeFormat)
+	diff(&restart, "timeZone", old.TimeZone, cfg.TimeZone)
+	diff(&restart, "multiline", old.Multiline, cfg.Multiline)
+	return
+}
+
+func samplingString(s *SamplingConfig) string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprint(*s)
+}
+
+func sinksString(sinks []SinkConfig) string {
+	items := make([]string, len(sinks))
+	for i, s := range sinks {
+		items[i] = s.Level + "=" + s.Path
+	}
+	return strings.Join(items, ",")
+}
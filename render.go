@@ -0,0 +1,349 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+/*
+Rendering of the arguments and the Any fields:
+	nil                      <nil>
+	bool, integers, floats   strconv, a uint8 is a number
+	string, []byte           as is
+	time.Time                RFC3339Nano
+	time.Duration            time.Duration.String
+	Lazy                     the rendering of its result
+	LogMarshaler             {key=value ...}
+	fmt.Formatter            %v
+	error                    Error(), the whole chain of the wrapped errors
+	fmt.Stringer             String()
+	pointer                  the rendering of the pointed value, <nil> for nil
+	struct, map, slice,      JSON: the json tags and `jlog:"redact"` honored,
+	array                    the map keys sorted, nil as null, the values of
+	                         the cases above as a JSON string of their
+	                         rendering
+	complex, chan, func      %v
+The panics of the methods are rendered as %!v(PANIC=Method method: value).
+*/
+
+// maxRenderDepth bounds the nesting of a JSON value, a cyclic one is cut
+// there.
+const maxRenderDepth = 32
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// jsonMarshaler is json.Marshaler, the output is written as is.
+type jsonMarshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+func appendArg2Buffer(buf *Buffer, arg interface{}) {
+	switch val := arg.(type) {
+	case nil:
+		_, _ = buf.WriteString("<nil>")
+	case bool:
+		buf.AppendBool(val)
+	case int:
+		buf.AppendInt(int64(val))
+	case int8:
+		buf.AppendInt(int64(val))
+	case int16:
+		buf.AppendInt(int64(val))
+	case int32:
+		buf.AppendInt(int64(val))
+	case int64:
+		buf.AppendInt(val)
+	case uint:
+		buf.AppendUint(uint64(val))
+	case uint8:
+		buf.AppendUint(uint64(val))
+	case uint16:
+		buf.AppendUint(uint64(val))
+	case uint32:
+		buf.AppendUint(uint64(val))
+	case uint64:
+		buf.AppendUint(val)
+	case float32:
+		buf.AppendFloat(float64(val), 32)
+	case float64:
+		buf.AppendFloat(val, 64)
+	case []byte:
+		_, _ = buf.Write(val)
+	case string:
+		_, _ = buf.WriteString(val)
+	case time.Time:
+		buf.AppendTime(val, time.RFC3339Nano)
+	case time.Duration:
+		appendDuration(buf, val)
+	case Lazy:
+		appendArg2Buffer(buf, val())
+	case LogMarshaler:
+		appendMarshaler(buf, val)
+	case fmt.Formatter:
+		appendFormatter(buf, val)
+	case error:
+		appendError(buf, val)
+	case fmt.Stringer:
+		appendStringer(buf, val)
+	default:
+		appendValue2Buffer(buf, reflect.ValueOf(arg))
+	}
+}
+
+// appendValue2Buffer renders the values of the types without a case in
+// appendArg2Buffer, by their kind.
+func appendValue2Buffer(buf *Buffer, val reflect.Value) {
+	switch val.Kind() {
+	case reflect.Bool:
+		buf.AppendBool(val.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.AppendInt(val.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.AppendUint(val.Uint())
+	case reflect.Float32:
+		buf.AppendFloat(val.Float(), 32)
+	case reflect.Float64:
+		buf.AppendFloat(val.Float(), 64)
+	case reflect.String:
+		_, _ = buf.WriteString(val.String())
+	case reflect.Ptr:
+		if val.IsNil() {
+			_, _ = buf.WriteString("<nil>")
+			return
+		}
+		appendArg2Buffer(buf, val.Elem().Interface())
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		appendJSONValue(buf, val, 0)
+	default:
+		_, _ = fmt.Fprintf(buf, "%v", val.Interface())
+	}
+}
+
+func appendFormatter(buf *Buffer, f fmt.Formatter) {
+	if isNilPointer(f) {
+		_, _ = buf.WriteString("<nil>")
+		return
+	}
+	_, _ = fmt.Fprintf(buf, "%v", f) // fmt recovers the panics itself
+}
+
+func appendError(buf *Buffer, err error) {
+	if isNilPointer(err) {
+		_, _ = buf.WriteString("<nil>")
+		return
+	}
+	defer recoverPanic(buf, "Error")
+	_, _ = buf.WriteString(err.Error())
+}
+
+// appendJSONValue writes v as JSON, with the values of the `jlog:"redact"`
+// fields replaced by Redacted.
+func appendJSONValue(buf *Buffer, v reflect.Value, depth int) {
+	if !v.IsValid() {
+		_, _ = buf.WriteString("null")
+		return
+	}
+	if depth > maxRenderDepth {
+		_, _ = buf.WriteString(`"<max depth>"`)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			_, _ = buf.WriteString("null")
+			return
+		}
+	}
+	if v.Type().NumMethod() > 0 && v.CanInterface() && appendJSONMethod(buf, v) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		buf.AppendBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.AppendInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.AppendUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f, bits := v.Float(), v.Type().Bits()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			buf.AppendJSONString(strconv.FormatFloat(f, 'f', -1, bits))
+			return
+		}
+		buf.AppendFloat(f, bits)
+	case reflect.String:
+		buf.AppendJSONString(v.String())
+	case reflect.Ptr, reflect.Interface:
+		appendJSONValue(buf, v.Elem(), depth+1)
+	case reflect.Struct:
+		_ = buf.WriteByte('{')
+		first := true
+		for _, f := range cachedFields(v.Type()) {
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+				continue
+			}
+			if !first {
+				_ = buf.WriteByte(',')
+			}
+			first = false
+			buf.AppendJSONString(f.name)
+			_ = buf.WriteByte(':')
+			if f.redact {
+				buf.AppendJSONString(Redacted)
+				continue
+			}
+			appendJSONValue(buf, fv, depth+1)
+		}
+		_ = buf.WriteByte('}')
+	case reflect.Map:
+		if v.IsNil() {
+			_, _ = buf.WriteString("null")
+			return
+		}
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		order := make([]int, len(keys))
+		for i, k := range keys {
+			names[i] = mapKeyString(k)
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool { return names[order[i]] < names[order[j]] })
+		_ = buf.WriteByte('{')
+		for n, i := range order {
+			if n > 0 {
+				_ = buf.WriteByte(',')
+			}
+			buf.AppendJSONString(names[i])
+			_ = buf.WriteByte(':')
+			appendJSONValue(buf, v.MapIndex(keys[i]), depth+1)
+		}
+		_ = buf.WriteByte('}')
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice {
+			if v.IsNil() {
+				_, _ = buf.WriteString("null")
+				return
+			}
+			if v.Type().Elem().Kind() == reflect.Uint8 && v.Type().Elem().NumMethod() == 0 {
+				buf.AppendJSONString(string(v.Bytes()))
+				return
+			}
+		}
+		_ = buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				_ = buf.WriteByte(',')
+			}
+			appendJSONValue(buf, v.Index(i), depth+1)
+		}
+		_ = buf.WriteByte(']')
+	default:
+		appendJSONText(buf, func() { _, _ = fmt.Fprintf(buf, "%v", v) })
+	}
+}
+
+// appendJSONMethod writes the values rendered by their methods, reporting
+// false for the others.
+func appendJSONMethod(buf *Buffer, v reflect.Value) bool {
+	switch val := v.Interface().(type) {
+	case time.Time:
+		appendJSONText(buf, func() { buf.AppendTime(val, time.RFC3339Nano) })
+	case time.Duration:
+		appendJSONText(buf, func() { appendDuration(buf, val) })
+	case jsonMarshaler:
+		appendMarshalJSON(buf, val)
+	case LogMarshaler, fmt.Formatter, error, fmt.Stringer:
+		appendJSONText(buf, func() { appendArg2Buffer(buf, val) })
+	case encoding.TextMarshaler:
+		appendMarshalText(buf, val)
+	default:
+		return false
+	}
+	return true
+}
+
+func appendMarshalJSON(buf *Buffer, m jsonMarshaler) {
+	defer recoverJSONPanic(buf, buf.Len(), "MarshalJSON")
+	data, err := m.MarshalJSON()
+	if err != nil {
+		buf.AppendJSONString(err.Error())
+		return
+	}
+	// an invalid or multi-line output would break the record.
+	var compact bytes.Buffer
+	if err = json.Compact(&compact, data); err != nil {
+		buf.AppendJSONString(string(data))
+		return
+	}
+	_, _ = buf.Write(compact.Bytes())
+}
+
+func appendMarshalText(buf *Buffer, m encoding.TextMarshaler) {
+	defer recoverJSONPanic(buf, buf.Len(), "MarshalText")
+	text, err := m.MarshalText()
+	if err != nil {
+		buf.AppendJSONString(err.Error())
+		return
+	}
+	buf.AppendJSONString(string(text))
+}
+
+// recoverJSONPanic writes a panic of the method as a JSON string in place
+// of what was written from mark.
+func recoverJSONPanic(buf *Buffer, mark int, method string) {
+	if r := recover(); r != nil {
+		buf.Truncate(mark)
+		appendJSONText(buf, func() { _, _ = fmt.Fprintf(buf, "%%!v(PANIC=%s method: %v)", method, r) })
+	}
+}
+
+// appendJSONText writes what fn appends to buf as a JSON string.
+func appendJSONText(buf *Buffer, fn func()) {
+	mark := buf.Len()
+	fn()
+	text := string(buf.Bytes()[mark:])
+	buf.Truncate(mark)
+	buf.AppendJSONString(text)
+}
+
+func mapKeyString(k reflect.Value) string {
+	switch k.Kind() {
+	case reflect.String:
+		return k.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10)
+	}
+	if k.Type().Implements(textMarshalerType) && k.CanInterface() {
+		if text, ok := mapKeyText(k.Interface().(encoding.TextMarshaler)); ok {
+			return text
+		}
+	}
+	return fmt.Sprint(k)
+}
+
+func mapKeyText(m encoding.TextMarshaler) (key string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			key, ok = fmt.Sprintf("%%!v(PANIC=MarshalText method: %v)", r), true
+		}
+	}()
+	text, err := m.MarshalText()
+	return string(text), err == nil
+}
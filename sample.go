@@ -0,0 +1,62 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// sampler keeps the first initial records of a level every second, then
+// one in thereafter.
+type sampler struct {
+	initial    uint64
+	thereafter uint64
+	counts     [MaxLevel]uint64 // the second in the high 32 bits, the count of the records in it below
+}
+
+func (s *sampler) _Keep(lv Level, now time.Time) bool {
+	c := &s.counts[lv]
+	sec := uint64(uint32(now.Unix())) << 32
+	var n uint64
+	for {
+		old := atomic.LoadUint64(c)
+		next := sec | 1
+		if old&^math.MaxUint32 == sec {
+			next = old + 1
+		}
+		if atomic.CompareAndSwapUint64(c, old, next) {
+			n = next & math.MaxUint32
+			break
+		}
+	}
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
+
+func (l *logger) _SetSampling(initial, thereafter int) {
+	var p unsafe.Pointer
+	if initial > 0 {
+		if thereafter < 0 {
+			thereafter = 0
+		}
+		p = unsafe.Pointer(&sampler{initial: uint64(initial), thereafter: uint64(thereafter)})
+	}
+	atomic.StorePointer(&l.sampling, p)
+}
+
+// _Allow reports whether a record of level lv and prefix is written, under
+// the levels and the sampling.
+func (l *logger) _Allow(lv Level, prefix string) bool {
+	if !l._Enabled(lv, prefix) {
+		return false
+	}
+	s := (*sampler)(atomic.LoadPointer(&l.sampling))
+	return s == nil || s._Keep(lv, l.file.clock.Now())
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"bytes"
+	"log"
+)
+
+// stdLogDepth skips the log package frames between the user call
+// (log.Printf, log.Println, ...) and stdWriter.Write.
+const stdLogDepth = 2
+
+// stdWriter receives one formatted message per Write from a *log.Logger.
+type stdWriter struct {
+	lg Logger
+	lv Level
+}
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	p = bytes.TrimSuffix(p, []byte{'\n'})
+	// p is reused by the log package once Write returns, so copy it.
+	_Output(w.lg, w.lv, "", stdLogDepth, string(p))
+	return n, nil
+}
+
+// NewStdLog returns a *log.Logger that writes every message to lg at level lv.
+// The standard prefix and flags are turned off, jlog adds its own header.
+func NewStdLog(lg Logger, lv Level) *log.Logger {
+	return log.New(&stdWriter{lg: lg, lv: lv}, "", 0)
+}
+
+// RedirectStdLog routes the output of the standard log package to the default
+// logger at level lv. It returns a function restoring the previous settings.
+func RedirectStdLog(lv Level) func() {
+	flags, prefix, out := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	// a CustomLogger without logger follows SetDefault.
+	log.SetOutput(&stdWriter{lg: &CustomLogger{level: ERROR}, lv: lv})
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(out)
+	}
+}
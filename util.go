@@ -6,6 +6,9 @@ package jlog
 
 import (
 	"os"
+	"runtime"
+	"strings"
+	"time"
 )
 
 // refer to: https://github.com/golang/glog
@@ -16,6 +19,20 @@ const (
 	digits     = "0123456789"
 )
 
+// caller returns the base file name and line of the frame skip levels up,
+// runtime.Caller allocates, Callers with FuncForPC does not.
+func caller(skip int) (file string, line int) {
+	var pcs [1]uintptr
+	if runtime.Callers(skip, pcs[:]) == 0 {
+		return "???", 1
+	}
+	file, line = runtime.FuncForPC(pcs[0] - 1).FileLine(pcs[0] - 1)
+	if slash := strings.LastIndex(file, "/"); slash >= 0 {
+		file = file[slash+1:]
+	}
+	return file, line
+}
+
 // twoDigits formats a zero-prefixed two-digit integer at buf.tmp[i].
 func twoDigits(tmp []byte, i, d int) {
 	tmp[i+1] = digits[d%10]
@@ -61,3 +78,116 @@ func WithoutExt(path string) string {
 	}
 	return path
 }
+
+// appendDuration writes d like d.String() without allocating,
+// refer to: time.Duration.String.
+func appendDuration(buf *Buffer, d time.Duration) {
+	// Largest time is 2540400h10m10.000000000s
+	var tmp [32]byte
+	w := len(tmp)
+
+	u := uint64(d)
+	neg := d < 0
+	if neg {
+		u = -u
+	}
+
+	if u < uint64(time.Second) {
+		// Special case: if duration is smaller than a second,
+		// use smaller units, like 1.2ms
+		var prec int
+		w--
+		tmp[w] = 's'
+		w--
+		switch {
+		case u == 0:
+			_, _ = buf.WriteString("0s")
+			return
+		case u < uint64(time.Microsecond):
+			prec = 0
+			tmp[w] = 'n'
+		case u < uint64(time.Millisecond):
+			prec = 3
+			// U+00B5 'µ' micro sign == 0xC2 0xB5
+			w--
+			copy(tmp[w:], "µ")
+		default:
+			prec = 6
+			tmp[w] = 'm'
+		}
+		w, u = fmtFrac(tmp[:w], u, prec)
+		w = fmtInt(tmp[:w], u)
+	} else {
+		w--
+		tmp[w] = 's'
+
+		w, u = fmtFrac(tmp[:w], u, 9)
+
+		// u is now integer seconds
+		w = fmtInt(tmp[:w], u%60)
+		u /= 60
+
+		// u is now integer minutes
+		if u > 0 {
+			w--
+			tmp[w] = 'm'
+			w = fmtInt(tmp[:w], u%60)
+			u /= 60
+
+			// u is now integer hours
+			// Stop at hours because days can be different lengths.
+			if u > 0 {
+				w--
+				tmp[w] = 'h'
+				w = fmtInt(tmp[:w], u)
+			}
+		}
+	}
+
+	if neg {
+		w--
+		tmp[w] = '-'
+	}
+	_, _ = buf.Write(tmp[w:])
+}
+
+// fmtFrac formats the fraction of v/10**prec (e.g., ".12345") into the
+// tail of buf, omitting trailing zeros. It omits the decimal
+// point too when the fraction is 0. It returns the index where the
+// output bytes begin and the value v/10**prec.
+func fmtFrac(buf []byte, v uint64, prec int) (nw int, nv uint64) {
+	// Omit trailing zeros up to and including decimal point.
+	w := len(buf)
+	print := false
+	for i := 0; i < prec; i++ {
+		digit := v % 10
+		print = print || digit != 0
+		if print {
+			w--
+			buf[w] = byte(digit) + '0'
+		}
+		v /= 10
+	}
+	if print {
+		w--
+		buf[w] = '.'
+	}
+	return w, v
+}
+
+// fmtInt formats v into the tail of buf.
+// It returns the index where the output begins.
+func fmtInt(buf []byte, v uint64) int {
+	w := len(buf)
+	if v == 0 {
+		w--
+		buf[w] = '0'
+	} else {
+		for v > 0 {
+			w--
+			buf[w] = byte(v%10) + '0'
+			v /= 10
+		}
+	}
+	return w
+}
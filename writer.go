@@ -0,0 +1,86 @@
+// Copyright 2021 The tiger Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jlog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// maxLineSize bounds a line, longer lines are split into records.
+const maxLineSize = 1 << 16
+
+type lineWriter struct {
+	mu     sync.Mutex
+	lg     Logger
+	lv     Level
+	prefix string
+	line   []byte
+	closed bool
+}
+
+// Writer returns an io.WriteCloser that logs every line written to it as a
+// record of level lv, the empty lines are skipped. Partial lines are kept
+// until their newline arrives, the ones past 64KiB are split, Close logs
+// what is left. It is meant for subprocess output and libraries
+// that only accept an io.Writer.
+func Writer(lg Logger, lv Level, prefix string) io.WriteCloser {
+	return &lineWriter{lg: lg, lv: lv, prefix: prefix}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		end := i
+		if i < 0 {
+			end = len(p)
+		}
+		if room := maxLineSize - len(w.line); end > room {
+			// a long line is cut exactly at maxLineSize.
+			w.line = append(w.line, p[:room]...)
+			w._Emit()
+			p = p[room:]
+			continue
+		}
+		w.line = append(w.line, p[:end]...)
+		if i < 0 {
+			break
+		}
+		w._Emit()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	if len(w.line) > 0 {
+		w._Emit()
+	}
+	w.closed = true
+	return nil
+}
+
+// _Emit logs the pending line, unless it is empty, and resets it.
+func (w *lineWriter) _Emit() {
+	if line := bytes.TrimSuffix(w.line, []byte{'\r'}); len(line) > 0 {
+		// the records are formatted later, so the line can not share w.line.
+		_Output(w.lg, w.lv, w.prefix, 1, string(line))
+	}
+	w.line = w.line[:0]
+}